@@ -0,0 +1,301 @@
+// Backups of the data apps store, as gzipped tar archives.
+//
+// An archive starts with its manifest and the workspace config of the app,
+// followed by the contents of every named volume under volumes/<name>/ and
+// of every read-write bind under binds/<index>/, the index being the one of
+// the host directory in the manifest. Archives are named after the app and
+// the time they were taken, which retention relies on.
+
+package backup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"ayayushsharma/rocket/common"
+	"ayayushsharma/rocket/containers"
+)
+
+// Version of the archive layout, archives of newer versions are refused
+const manifestVersion = 1
+
+const (
+	manifestEntry = "manifest.json"
+	configEntry   = "config.json"
+	volumesDir    = "volumes"
+	bindsDir      = "binds"
+)
+
+// Time format in archive names, sorting the same way as the times do
+const nameTimeFormat = "20060102T150405Z"
+
+const archiveExt = ".tar.gz"
+
+var UnsupportedArchiveErr = errors.New("not a rocket backup or written by a newer rocket")
+
+type Manifest struct {
+	Version int `json:"version"`
+
+	// workspace entry the backup was taken of
+	App string `json:"app"`
+
+	Created time.Time `json:"created"`
+
+	// named volumes of the app. Volumes that did not exist have no contents
+	Volumes []string `json:"volumes,omitempty"`
+
+	// host directories of read-write binds in the archive
+	Binds []string `json:"binds,omitempty"`
+}
+
+// Archive being written. Written to a temporary file that only takes the
+// name of the archive once closed, so that failed backups never count as
+// backups
+type Writer struct {
+	path    string
+	file    *os.File
+	gzip    *gzip.Writer
+	tar     *tar.Writer
+	volumes map[string]bool
+}
+
+// Name of the archive of the app taken at the time
+func FileName(appName string, at time.Time) string {
+	return common.ShortenAppName(appName) + "-" + at.UTC().Format(nameTimeFormat) + archiveExt
+}
+
+// Starts the archive with the manifest and config of the app
+func Create(archive string, manifest Manifest, appCfg containers.Config) (
+	w *Writer, err error,
+) {
+	if err = os.MkdirAll(filepath.Dir(archive), 0755); err != nil {
+		return nil, err
+	}
+	file, err := os.CreateTemp(filepath.Dir(archive), "."+filepath.Base(archive)+"-*")
+	if err != nil {
+		return nil, err
+	}
+
+	w = &Writer{
+		path:    archive,
+		file:    file,
+		volumes: map[string]bool{},
+	}
+	w.gzip = gzip.NewWriter(file)
+	w.tar = tar.NewWriter(w.gzip)
+
+	manifest.Version = manifestVersion
+	if err = w.writeJSON(manifestEntry, manifest); err != nil {
+		w.Abort()
+		return nil, err
+	}
+	if err = w.writeJSON(configEntry, appCfg); err != nil {
+		w.Abort()
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *Writer) writeJSON(name string, value any) error {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return err
+	}
+	header := &tar.Header{
+		Name:    name,
+		Mode:    0644,
+		Size:    int64(len(data)),
+		ModTime: time.Now(),
+	}
+	if err = w.tar.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err = w.tar.Write(data)
+	return err
+}
+
+// Adds the contents of the volume. export writes them as a tar archive
+func (w *Writer) AddVolume(volumeName string, export func(io.Writer) error) error {
+	if w.volumes[volumeName] {
+		return fmt.Errorf("volume %q added twice", volumeName)
+	}
+	w.volumes[volumeName] = true
+
+	reader, writer := io.Pipe()
+	exported := make(chan error, 1)
+	go func() {
+		err := export(writer)
+		writer.CloseWithError(err)
+		exported <- err
+	}()
+
+	err := w.copyEntries(tar.NewReader(reader), path.Join(volumesDir, volumeName))
+	// unblock the export if copying stopped early
+	reader.CloseWithError(err)
+	if exportErr := <-exported; exportErr != nil {
+		return exportErr
+	}
+	return err
+}
+
+// Copies every entry of the archive below prefix
+func (w *Writer) copyEntries(reader *tar.Reader, prefix string) error {
+	for {
+		header, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		name := path.Clean(strings.TrimPrefix(header.Name, "/"))
+		if name == "." {
+			continue
+		}
+		header.Name = path.Join(prefix, name)
+		if header.Typeflag == tar.TypeDir {
+			header.Name += "/"
+		}
+		if header.Typeflag == tar.TypeLink {
+			header.Linkname = path.Join(prefix, path.Clean(header.Linkname))
+		}
+
+		if err = w.tar.WriteHeader(header); err != nil {
+			return err
+		}
+		if _, err = io.Copy(w.tar, reader); err != nil {
+			return err
+		}
+	}
+}
+
+// Adds the host directory of a bind, index being its position in the
+// manifest
+func (w *Writer) AddBind(index int, dir string) error {
+	prefix := path.Join(bindsDir, strconv.Itoa(index))
+
+	return filepath.WalkDir(dir, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, file)
+		if err != nil || rel == "." {
+			return err
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		link := ""
+		switch {
+		case info.Mode()&fs.ModeSymlink != 0:
+			if link, err = os.Readlink(file); err != nil {
+				return err
+			}
+		case info.IsDir(), info.Mode().IsRegular():
+		default:
+			// sockets, fifos and devices are recreated by apps, not restored
+			return nil
+		}
+
+		header, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		header.Name = path.Join(prefix, filepath.ToSlash(rel))
+		if info.IsDir() {
+			header.Name += "/"
+		}
+		if err = w.tar.WriteHeader(header); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(w.tar, f)
+		return err
+	})
+}
+
+// Finishes the archive and moves it to its name
+func (w *Writer) Close() error {
+	err := errors.Join(w.tar.Close(), w.gzip.Close(), w.file.Sync(), w.file.Close())
+	if err != nil {
+		os.Remove(w.file.Name())
+		return err
+	}
+	return os.Rename(w.file.Name(), w.path)
+}
+
+// Drops the unfinished archive
+func (w *Writer) Abort() {
+	w.file.Close()
+	os.Remove(w.file.Name())
+}
+
+// Archives of the app in dir, oldest first
+func List(dir string, appName string) (archives []string, err error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := common.ShortenAppName(appName) + "-"
+	for _, entry := range entries {
+		stamp, ok := strings.CutPrefix(entry.Name(), prefix)
+		if !ok || entry.IsDir() {
+			continue
+		}
+		stamp, ok = strings.CutSuffix(stamp, archiveExt)
+		if !ok {
+			continue
+		}
+		// names of other apps can share the prefix, not the time after it
+		if _, err := time.Parse(nameTimeFormat, stamp); err != nil {
+			continue
+		}
+		archives = append(archives, filepath.Join(dir, entry.Name()))
+	}
+	slices.Sort(archives)
+	return archives, nil
+}
+
+// Removes all but the newest keep archives of the app in dir. Returns the
+// removed archives
+func Prune(dir string, appName string, keep int) (removed []string, err error) {
+	archives, err := List(dir, appName)
+	if err != nil || len(archives) <= keep {
+		return nil, err
+	}
+
+	for _, archive := range archives[:len(archives)-keep] {
+		if err = os.Remove(archive); err != nil {
+			return removed, err
+		}
+		removed = append(removed, archive)
+	}
+	return removed, nil
+}
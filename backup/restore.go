@@ -0,0 +1,338 @@
+package backup
+
+import (
+	"archive/tar"
+	"cmp"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"ayayushsharma/rocket/containers"
+)
+
+// Archive being restored. Manifest and config are read on opening, volumes
+// and binds by Restore
+type Reader struct {
+	Manifest Manifest
+	Config   containers.Config
+
+	// host directories binds are written to, taken from the mounts of the
+	// config rather than trusted from the manifest
+	binds []string
+
+	// symlinks of binds, created once every other entry is written so that
+	// no entry is written through a symlink of the archive
+	symlinks []bindSymlink
+
+	file *os.File
+	gzip *gzip.Reader
+	tar  *tar.Reader
+}
+
+type bindSymlink struct {
+	target   string
+	linkname string
+}
+
+// Open reads the manifest and config at the start of the archive
+func Open(archive string) (r *Reader, err error) {
+	file, err := os.Open(archive)
+	if err != nil {
+		return nil, err
+	}
+	reader := &Reader{file: file}
+	defer func() {
+		if err != nil {
+			reader.Close()
+		}
+	}()
+
+	if reader.gzip, err = gzip.NewReader(file); err != nil {
+		return nil, fmt.Errorf("%w: %w", UnsupportedArchiveErr, err)
+	}
+	reader.tar = tar.NewReader(reader.gzip)
+
+	if err = reader.readJSON(manifestEntry, &reader.Manifest); err != nil {
+		return nil, err
+	}
+	if reader.Manifest.Version < 1 || reader.Manifest.Version > manifestVersion {
+		return nil, UnsupportedArchiveErr
+	}
+	if err = reader.readJSON(configEntry, &reader.Config); err != nil {
+		return nil, err
+	}
+	if err = reader.checkMounts(); err != nil {
+		return nil, err
+	}
+	return reader, nil
+}
+
+// Refuses manifests listing other volumes or binds than the config mounts,
+// binds of the archive are only ever written to the directories the app
+// mounts
+func (r *Reader) checkMounts() error {
+	volumes, binds := r.Config.DataMounts()
+	if r.Manifest.App != r.Config.ContainerName ||
+		!slices.Equal(r.Manifest.Volumes, volumes) ||
+		!slices.Equal(r.Manifest.Binds, binds) {
+		return fmt.Errorf("%w: manifest does not match the mounts of the app", UnsupportedArchiveErr)
+	}
+	for _, dir := range binds {
+		if !filepath.IsAbs(dir) {
+			return fmt.Errorf("%w: bind %q is not an absolute path", UnsupportedArchiveErr, dir)
+		}
+	}
+	r.binds = binds
+	return nil
+}
+
+// Host directories the binds of the archive are restored to
+func (r *Reader) Binds() []string {
+	return slices.Clone(r.binds)
+}
+
+func (r *Reader) readJSON(name string, value any) error {
+	header, err := r.tar.Next()
+	if err != nil {
+		return fmt.Errorf("%w: %w", UnsupportedArchiveErr, err)
+	}
+	if header.Name != name {
+		return fmt.Errorf("%w: %s missing", UnsupportedArchiveErr, name)
+	}
+	return json.NewDecoder(r.tar).Decode(value)
+}
+
+func (r *Reader) Close() error {
+	if r.gzip != nil {
+		r.gzip.Close()
+	}
+	return r.file.Close()
+}
+
+// Hands the contents of every volume to importVolume as a tar archive and
+// writes binds into their host directories. Files missing from the archive
+// are kept. Consumes the rest of the archive
+func (r *Reader) Restore(
+	importVolume func(volumeName string, contents io.Reader) error,
+) (err error) {
+	volume := volumeImport{importVolume: importVolume}
+	defer func() {
+		if err != nil {
+			volume.abort(err)
+			return
+		}
+		err = volume.finish()
+	}()
+
+	for {
+		header, err := r.tar.Next()
+		if errors.Is(err, io.EOF) {
+			return r.createSymlinks()
+		}
+		if err != nil {
+			return err
+		}
+
+		section, name, _ := strings.Cut(header.Name, "/")
+		switch section {
+		case volumesDir:
+			volumeName, _, _ := strings.Cut(name, "/")
+			if !slices.Contains(r.Manifest.Volumes, volumeName) {
+				return fmt.Errorf("volume %q is not in the manifest", volumeName)
+			}
+			if err = volume.add(volumeName, header, r.tar); err != nil {
+				return err
+			}
+		case bindsDir:
+			if err = volume.finish(); err != nil {
+				return err
+			}
+			if err = r.extractBind(name, header); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("unexpected entry %q in archive", header.Name)
+		}
+	}
+}
+
+// Streams the entries of one volume at a time into its import
+type volumeImport struct {
+	importVolume func(volumeName string, contents io.Reader) error
+
+	name     string
+	pipe     *io.PipeWriter
+	tar      *tar.Writer
+	imported chan error
+}
+
+// Adds the entry to the import of its volume, finishing the import of the
+// previous volume first
+func (v *volumeImport) add(volumeName string, header *tar.Header, contents io.Reader) error {
+	if volumeName != v.name {
+		if err := v.finish(); err != nil {
+			return err
+		}
+		v.start(volumeName)
+	}
+
+	prefix := path.Join(volumesDir, volumeName) + "/"
+	header.Name = strings.TrimPrefix(header.Name, prefix)
+	if header.Name == "" {
+		return nil
+	}
+	if header.Typeflag == tar.TypeLink {
+		header.Linkname = strings.TrimPrefix(header.Linkname, prefix)
+	}
+
+	if err := v.tar.WriteHeader(header); err != nil {
+		return v.failed(err)
+	}
+	if _, err := io.Copy(v.tar, contents); err != nil {
+		return v.failed(err)
+	}
+	return nil
+}
+
+func (v *volumeImport) start(volumeName string) {
+	reader, writer := io.Pipe()
+	v.name = volumeName
+	v.pipe = writer
+	v.tar = tar.NewWriter(writer)
+	v.imported = make(chan error, 1)
+
+	go func() {
+		err := v.importVolume(volumeName, reader)
+		// unblock writes when the import stopped reading early
+		reader.CloseWithError(cmp.Or(err, io.ErrClosedPipe))
+		v.imported <- err
+	}()
+}
+
+// Errors of writing to an import that stopped are reported as the error it
+// stopped with
+func (v *volumeImport) failed(err error) error {
+	volumeName := v.name
+	v.pipe.CloseWithError(err)
+	if importErr := <-v.imported; importErr != nil {
+		err = importErr
+	}
+	v.name, v.tar = "", nil
+	return fmt.Errorf("restore volume %q: %w", volumeName, err)
+}
+
+// Ends the archive of the current volume and waits for its import
+func (v *volumeImport) finish() error {
+	if v.tar == nil {
+		return nil
+	}
+	volumeName := v.name
+	err := v.tar.Close()
+	v.pipe.CloseWithError(err)
+	importErr := <-v.imported
+	v.name, v.tar = "", nil
+
+	if err = cmp.Or(importErr, err); err != nil {
+		return fmt.Errorf("restore volume %q: %w", volumeName, err)
+	}
+	return nil
+}
+
+// Fails the import of the current volume, so that it does not restore a
+// part of the volume
+func (v *volumeImport) abort(err error) {
+	if v.tar == nil {
+		return
+	}
+	v.pipe.CloseWithError(err)
+	<-v.imported
+	v.name, v.tar = "", nil
+}
+
+// Writes the entry into the host directory of its bind, name being relative
+// to binds/
+func (r *Reader) extractBind(name string, header *tar.Header) error {
+	indexPart, rel, _ := strings.Cut(name, "/")
+	index, err := strconv.Atoi(indexPart)
+	if err != nil || index < 0 || index >= len(r.binds) {
+		return fmt.Errorf("bind %q is not in the manifest", indexPart)
+	}
+	dir := r.binds[index]
+
+	rel = strings.TrimSuffix(rel, "/")
+	if rel == "" {
+		return nil
+	}
+	if !filepath.IsLocal(rel) {
+		return fmt.Errorf("entry %q leaves its bind", header.Name)
+	}
+	target := filepath.Join(dir, filepath.FromSlash(rel))
+	mode := header.FileInfo().Mode().Perm()
+
+	if header.Typeflag == tar.TypeDir {
+		if err = os.MkdirAll(target, 0755); err != nil {
+			return err
+		}
+		return os.Chmod(target, mode)
+	}
+
+	if err = os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	// replaced rather than written through, target may be a symlink
+	if err = os.Remove(target); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	switch header.Typeflag {
+	case tar.TypeReg:
+		file, err := os.OpenFile(target, os.O_CREATE|os.O_EXCL|os.O_WRONLY, mode)
+		if err != nil {
+			return err
+		}
+		if _, err = io.Copy(file, r.tar); err != nil {
+			file.Close()
+			return err
+		}
+		if err = file.Close(); err != nil {
+			return err
+		}
+		return os.Chtimes(target, header.AccessTime, header.ModTime)
+	case tar.TypeSymlink:
+		r.symlinks = append(r.symlinks, bindSymlink{
+			target:   target,
+			linkname: header.Linkname,
+		})
+		return nil
+	case tar.TypeLink:
+		prefix := path.Join(bindsDir, indexPart) + "/"
+		linked, ok := strings.CutPrefix(header.Linkname, prefix)
+		if !ok || !filepath.IsLocal(linked) {
+			return fmt.Errorf("entry %q links out of its bind", header.Name)
+		}
+		return os.Link(filepath.Join(dir, filepath.FromSlash(linked)), target)
+	}
+	return nil
+}
+
+// Creates the symlinks of binds collected while extracting
+func (r *Reader) createSymlinks() error {
+	for _, link := range r.symlinks {
+		if err := os.Remove(link.target); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		if err := os.Symlink(link.linkname, link.target); err != nil {
+			return err
+		}
+	}
+	r.symlinks = nil
+	return nil
+}
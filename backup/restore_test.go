@@ -0,0 +1,161 @@
+package backup
+
+import (
+	"archive/tar"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"ayayushsharma/rocket/containers"
+)
+
+// Config of an app with a single read-write bind of dir
+func bindApp(dir string) containers.Config {
+	return containers.Config{
+		ContainerName: "rocket-backup",
+		ImageURL:      "docker.io/library/busybox",
+		MountDirs: containers.MountList{{
+			Type:        containers.MountBind,
+			Source:      dir,
+			Destination: "/data",
+		}},
+	}
+}
+
+// Writes an archive of appCfg with the manifest, then the entries as they
+// are given
+func writeArchive(
+	t *testing.T,
+	manifest Manifest,
+	appCfg containers.Config,
+	entries ...*tar.Header,
+) string {
+	t.Helper()
+
+	archive := filepath.Join(t.TempDir(), FileName(appCfg.ContainerName, time.Now()))
+	writer, err := Create(archive, manifest, appCfg)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	for _, header := range entries {
+		if err = writer.tar.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if header.Size > 0 {
+			if _, err = writer.tar.Write(make([]byte, header.Size)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err = writer.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	return archive
+}
+
+func TestBindRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "nested"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "nested", "data.txt"), []byte("data"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("nested/data.txt", filepath.Join(dir, "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	appCfg := bindApp(dir)
+	archive := filepath.Join(t.TempDir(), FileName(appCfg.ContainerName, time.Now()))
+	writer, err := Create(archive, Manifest{App: appCfg.ContainerName, Binds: []string{dir}}, appCfg)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if err = writer.AddBind(0, dir); err != nil {
+		t.Fatalf("AddBind: %v", err)
+	}
+	if err = writer.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	if err = os.RemoveAll(dir); err != nil {
+		t.Fatal(err)
+	}
+	reader, err := Open(archive)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer reader.Close()
+	if err = reader.Restore(nil); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "link"))
+	if err != nil {
+		t.Fatalf("read restored symlink: %v", err)
+	}
+	if string(data) != "data" {
+		t.Errorf("restored data = %q, want %q", data, "data")
+	}
+	info, err := os.Stat(filepath.Join(dir, "nested", "data.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("restored mode = %v, want %v", info.Mode().Perm(), os.FileMode(0600))
+	}
+}
+
+func TestOpenRefusesManifestOtherThanConfig(t *testing.T) {
+	dir := t.TempDir()
+	appCfg := bindApp(dir)
+	manifest := Manifest{
+		App:   appCfg.ContainerName,
+		Binds: []string{filepath.Join(t.TempDir(), "elsewhere")},
+	}
+	archive := writeArchive(t, manifest, appCfg)
+
+	_, err := Open(archive)
+	if !errors.Is(err, UnsupportedArchiveErr) {
+		t.Fatalf("Open error = %v, want %v", err, UnsupportedArchiveErr)
+	}
+}
+
+func TestRestoreDoesNotWriteThroughSymlinks(t *testing.T) {
+	dir := t.TempDir()
+	outside := t.TempDir()
+	appCfg := bindApp(dir)
+	manifest := Manifest{App: appCfg.ContainerName, Binds: []string{dir}}
+
+	archive := writeArchive(t, manifest, appCfg,
+		&tar.Header{
+			Typeflag: tar.TypeSymlink,
+			Name:     "binds/0/escape",
+			Linkname: outside,
+			Mode:     0777,
+		},
+		&tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     "binds/0/escape/planted",
+			Mode:     0644,
+			Size:     4,
+		},
+	)
+
+	reader, err := Open(archive)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer reader.Close()
+	err = reader.Restore(func(string, io.Reader) error { return nil })
+	if err == nil {
+		t.Error("Restore replaced a directory holding restored files with a symlink")
+	}
+
+	if _, err := os.Lstat(filepath.Join(outside, "planted")); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("file written outside of the bind through a symlink, stat error %v", err)
+	}
+}
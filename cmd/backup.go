@@ -0,0 +1,213 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"ayayushsharma/rocket/backup"
+	"ayayushsharma/rocket/common"
+	"ayayushsharma/rocket/constants"
+	"ayayushsharma/rocket/containers"
+	"ayayushsharma/rocket/workspace"
+)
+
+var backupCmd = &cobra.Command{
+	Use:   "backup [app...]",
+	Short: "Backs up the config and data of registered apps",
+	Long: `Writes a tarball per app holding its workspace config, its named volumes and
+its read-write bind mounts. Running containers are paused while their data
+is read, or stopped and started again with --stop.
+
+Archives are named after the app and the time they were taken. --keep removes
+older archives of the app, e.g. "rocket backup --all --keep 7" run daily from
+a systemd timer or cron keeps a week of backups.`,
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
+		ctx := cmd.Context()
+		conn, err := containerManager()
+		if err != nil {
+			return
+		}
+
+		appNames := []string{}
+		for _, appName := range args {
+			appNames = append(appNames, common.CompleteAppName(appName))
+		}
+		if viper.GetBool("all") {
+			apps, err := workspace.GetApps()
+			if err != nil {
+				return err
+			}
+			appNames = slices.Sorted(maps.Keys(apps))
+		}
+		if len(appNames) == 0 {
+			return errors.New("no app to back up, name apps or pass --all")
+		}
+
+		keep := viper.GetInt("keep")
+		if keep < 0 {
+			return fmt.Errorf("keep must not be negative, got %d", keep)
+		}
+
+		var storeErr error
+		for _, appName := range appNames {
+			// remaining apps would fail the same way once interrupted
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if err = backupApp(ctx, conn, appName, keep); err != nil {
+				fmt.Printf("Failed to back up %s: %v\n", common.ShortenAppName(appName), err)
+				storeErr = err
+			}
+		}
+		return storeErr
+	},
+	ValidArgsFunction: unregisterAppCompletionFn,
+}
+
+func init() {
+	rootCmd.AddCommand(backupCmd)
+	backupCmd.Flags().Bool("all", false, "Back up all the registered apps")
+	backupCmd.Flags().String("dir", constants.BackupDir, "Directory archives are written to")
+	backupCmd.Flags().Int("keep", 0, "Newest archives kept per app, older ones are removed. 0 keeps all")
+	backupCmd.Flags().Bool("stop", false, "Stop running apps while backing up instead of pausing them")
+}
+
+// Writes the archive of the app, then applies retention
+func backupApp(
+	ctx context.Context,
+	conn containers.ContainerManager,
+	appName string,
+	keep int,
+) (err error) {
+	appCfg, err := workspace.GetAppCfg(appName)
+	if err != nil {
+		return err
+	}
+
+	dir := viper.GetString("dir")
+	created := time.Now()
+	archive := filepath.Join(dir, backup.FileName(appName, created))
+	volumes, binds := appCfg.DataMounts()
+
+	resume, err := quiesceApp(ctx, conn, appCfg, viper.GetBool("stop"))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if resumeErr := resume(); resumeErr != nil {
+			err = errors.Join(err, fmt.Errorf("resume app: %w", resumeErr))
+		}
+	}()
+
+	writer, err := backup.Create(archive, backup.Manifest{
+		App:     appName,
+		Created: created,
+		Volumes: volumes,
+		Binds:   binds,
+	}, appCfg)
+	if err != nil {
+		return err
+	}
+
+	for _, volumeName := range volumes {
+		err = writer.AddVolume(volumeName, func(contents io.Writer) error {
+			return conn.ExportVolume(ctx, volumeName, contents)
+		})
+		// volumes are only created along with the app
+		if errors.Is(err, containers.VolumeDoesntExistErr) {
+			slog.Debug("Volume not created yet, skipped", "volume", volumeName)
+			continue
+		}
+		if err != nil {
+			writer.Abort()
+			return fmt.Errorf("volume %q: %w", volumeName, err)
+		}
+	}
+	for index, bindDir := range binds {
+		err = writer.AddBind(index, bindDir)
+		if errors.Is(err, os.ErrNotExist) {
+			slog.Debug("Bind source does not exist, skipped", "dir", bindDir)
+			continue
+		}
+		if err != nil {
+			writer.Abort()
+			return fmt.Errorf("bind %q: %w", bindDir, err)
+		}
+	}
+	if err = writer.Close(); err != nil {
+		return err
+	}
+	fmt.Printf("Backed up %s to %s\n", common.ShortenAppName(appName), archive)
+
+	if keep == 0 {
+		return nil
+	}
+	removed, err := backup.Prune(dir, appName, keep)
+	for _, old := range removed {
+		slog.Debug("Removed old backup", "archive", old)
+	}
+	return err
+}
+
+// Pauses the running containers of the app, or stops the app, so that its
+// data does not change while it is read. Returns the function bringing the
+// app back, which runs even after the backup is interrupted
+func quiesceApp(
+	ctx context.Context,
+	conn containers.ContainerManager,
+	appCfg containers.Config,
+	stop bool,
+) (resume func() error, err error) {
+	members := []containers.Config{appCfg}
+	if appCfg.IsStack() {
+		members = appCfg.StackContainers()
+	}
+
+	running := []string{}
+	for _, member := range members {
+		status, err := containerStatus(ctx, conn, member.ContainerName)
+		if err != nil {
+			return nil, err
+		}
+		if status.Running() {
+			running = append(running, member.ContainerName)
+		}
+	}
+	if len(running) == 0 {
+		return func() error { return nil }, nil
+	}
+
+	resumeCtx := context.WithoutCancel(ctx)
+	if stop {
+		if err = stopApp(ctx, conn, appCfg.ContainerName); err != nil {
+			return nil, err
+		}
+		return func() error { return startApp(resumeCtx, conn, appCfg) }, nil
+	}
+
+	paused := []string{}
+	resume = func() (err error) {
+		for _, containerName := range paused {
+			err = errors.Join(err, conn.UnpauseService(resumeCtx, containerName))
+		}
+		return err
+	}
+	for _, containerName := range running {
+		if err = conn.PauseService(ctx, containerName); err != nil {
+			return nil, errors.Join(err, resume())
+		}
+		paused = append(paused, containerName)
+	}
+	return resume, nil
+}
@@ -0,0 +1,221 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"slices"
+
+	"ayayushsharma/rocket/constants"
+	"ayayushsharma/rocket/containers"
+	"ayayushsharma/rocket/egress"
+	"ayayushsharma/rocket/workspace"
+)
+
+// Image the egress proxy container runs. Pinned in rockets.lock and
+// verified like the images of apps
+const (
+	egressProxyImageURL     = "docker.io/ubuntu/squid"
+	egressProxyImageVersion = "latest"
+)
+
+// Sets up what the egress policy of the app needs: the egress network for
+// internet access, the proxy for allowlists. Returns the config pointing the
+// app at its port of the proxy
+func prepareEgress(
+	ctx context.Context,
+	conn containers.ContainerManager,
+	appCfg containers.Config,
+) (containers.Config, error) {
+	switch appCfg.Egress.Mode() {
+	case containers.EgressInternet:
+		err := ensureNetwork(ctx, conn, constants.EgressNetwork, false)
+		return appCfg, err
+	case containers.EgressAllowlist:
+		port, err := startEgressProxy(ctx, conn, appCfg.ContainerName)
+		if err != nil {
+			return appCfg, fmt.Errorf("egress proxy: %w", err)
+		}
+		appCfg.EgressProxy = egress.ProxyURL(port)
+	}
+	return appCfg, nil
+}
+
+// Writes the proxy config for every registered app, then creates or starts
+// the proxy. A running proxy reloads a changed config and is created again
+// when the proxy networks it joins changed. Returns the port assigned to the
+// app
+func startEgressProxy(
+	ctx context.Context,
+	conn containers.ContainerManager,
+	appName string,
+) (port int, err error) {
+	apps, err := workspace.GetApps()
+	if err != nil {
+		return 0, err
+	}
+
+	proxyCfg := egressProxyConfig()
+	subnets := map[string][]string{}
+	for _, name := range slices.Sorted(maps.Keys(apps)) {
+		if apps[name].Egress.Mode() != containers.EgressAllowlist {
+			continue
+		}
+		networkName := containers.EgressProxyNetwork(name)
+		if err = ensureNetwork(ctx, conn, networkName, true); err != nil {
+			return 0, err
+		}
+		if subnets[name], err = conn.NetworkSubnets(ctx, networkName); err != nil {
+			return 0, err
+		}
+		proxyCfg.ProxyNetworks = append(proxyCfg.ProxyNetworks, networkName)
+	}
+
+	ports, changed, err := egress.Sync(apps, subnets)
+	if err != nil {
+		return 0, err
+	}
+	port, ok := ports[appName]
+	if !ok {
+		return 0, fmt.Errorf("%s has no egress allowlist", appName)
+	}
+
+	exists, err := conn.ContainerExists(ctx, constants.EgressProxyContainer)
+	if err != nil {
+		return 0, err
+	}
+	if !exists {
+		return port, createEgressProxy(ctx, conn, proxyCfg)
+	}
+
+	status, err := conn.InspectContainer(ctx, constants.EgressProxyContainer)
+	if err != nil {
+		return 0, err
+	}
+	// containers only join networks on creation
+	if !slices.Equal(status.Networks, slices.Sorted(slices.Values(proxyCfg.Networks()))) {
+		slog.Debug("Creating egress proxy again for changed networks")
+		err = conn.RemoveContainer(ctx, constants.EgressProxyContainer, true)
+		if err != nil {
+			return 0, err
+		}
+		return port, createEgressProxy(ctx, conn, proxyCfg)
+	}
+	if !status.Running() {
+		return port, conn.StartService(ctx, constants.EgressProxyContainer)
+	}
+	if changed {
+		slog.Debug("Reloading egress proxy config")
+		exitCode, err := conn.Exec(
+			ctx,
+			constants.EgressProxyContainer,
+			containers.ExecOptions{
+				Cmd:    []string{"squid", "-k", "reconfigure"},
+				Stdout: os.Stderr,
+				Stderr: os.Stderr,
+			},
+		)
+		if err != nil {
+			return 0, err
+		}
+		if exitCode != 0 {
+			return 0, fmt.Errorf("reloading proxy config exited with %d", exitCode)
+		}
+	}
+	return port, nil
+}
+
+// Proxy joins the egress network to reach the internet and the proxy
+// networks of the apps it serves. It stays off the routes network, so that
+// apps only reach it over their own proxy network
+func createEgressProxy(
+	ctx context.Context,
+	conn containers.ContainerManager,
+	proxyCfg containers.Config,
+) (err error) {
+	proxyCfg, err = pinApp(ctx, conn, proxyCfg, false)
+	if err != nil {
+		return err
+	}
+	imageExists, err := conn.ImageExists(ctx, proxyCfg.Image())
+	if err != nil {
+		return err
+	}
+	if !imageExists {
+		if err = pullImage(ctx, conn, proxyCfg.Image()); err != nil {
+			return err
+		}
+	}
+	if err = verifyApp(ctx, proxyCfg); err != nil {
+		return err
+	}
+
+	if err = ensureNetwork(ctx, conn, constants.EgressNetwork, false); err != nil {
+		return err
+	}
+	if err = conn.CreateContainer(ctx, proxyCfg); err != nil {
+		return err
+	}
+	slog.Debug("Created egress proxy")
+
+	return conn.StartService(ctx, constants.EgressProxyContainer)
+}
+
+// Resolves the pinned proxy image again. The proxy is only pinned once an
+// app needed it, an unpinned proxy is left as it is
+func refreshEgressProxyPin(
+	ctx context.Context,
+	conn containers.ContainerManager,
+) error {
+	pins, err := workspace.GetPins()
+	if err != nil {
+		return err
+	}
+	proxyCfg := egressProxyConfig()
+	if _, pinned := pins[proxyCfg.ImageRef()]; !pinned {
+		return nil
+	}
+	_, err = pinApp(ctx, conn, proxyCfg, true)
+	return err
+}
+
+// Config of the proxy container, without the proxy networks of apps
+func egressProxyConfig() containers.Config {
+	return containers.Config{
+		ImageURL:        egressProxyImageURL,
+		ImageVersion:    egressProxyImageVersion,
+		ContainerName:   constants.EgressProxyContainer,
+		ApplicationName: constants.EgressProxyContainer,
+		NetworkName:     constants.EgressNetwork,
+		MountDirs: containers.MountList{
+			{
+				Type:        containers.MountBind,
+				Source:      constants.EgressProxyConf,
+				Destination: egress.ProxyConfPath,
+				ReadOnly:    true,
+			},
+		},
+	}
+}
+
+// Creates the network unless it exists. Internal networks have no route out
+// of the host
+func ensureNetwork(
+	ctx context.Context,
+	conn containers.ContainerManager,
+	networkName string,
+	internal bool,
+) (err error) {
+	exists, err := conn.NetworkExists(ctx, networkName)
+	if err != nil || exists {
+		return err
+	}
+
+	if err = conn.CreateNetwork(ctx, networkName, internal); err != nil {
+		return err
+	}
+	slog.Debug("Created Network", "name", networkName, "internal", internal)
+	return nil
+}
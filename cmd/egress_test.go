@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"slices"
+	"testing"
+
+	"ayayushsharma/rocket/constants"
+	"ayayushsharma/rocket/containers"
+	"ayayushsharma/rocket/workspace"
+)
+
+// Registers a busybox app reaching only api.github.com
+func registerAllowlistedApp(t *testing.T, name string) containers.Config {
+	t.Helper()
+
+	appCfg := containers.Config{
+		ImageURL:        "docker.io/library/busybox",
+		ImageVersion:    "latest",
+		ContainerName:   name,
+		ApplicationName: name,
+		SubDomain:       name + ".app.localhost",
+		NetworkName:     testNetwork,
+		Egress: containers.Egress{
+			Policy: containers.EgressAllowlist,
+			Allow:  []string{"api.github.com"},
+		},
+	}
+	if err := workspace.Register(appCfg); err != nil {
+		t.Fatalf("Register(%q): %v", name, err)
+	}
+	return appCfg
+}
+
+func TestStartEgressProxyPinsImage(t *testing.T) {
+	useTempWorkspace(t)
+	conn := newTestManager(t)
+	registerAllowlistedApp(t, "rocket-egress-pin")
+
+	if _, err := startEgressProxy(t.Context(), conn, "rocket-egress-pin"); err != nil {
+		t.Fatalf("startEgressProxy: %v", err)
+	}
+
+	pins, err := workspace.GetPins()
+	if err != nil {
+		t.Fatalf("GetPins: %v", err)
+	}
+	proxyCfg := egressProxyConfig()
+	digest, ok := pins[proxyCfg.ImageRef()]
+	if !ok {
+		t.Fatalf("image %q not pinned, pins = %v", proxyCfg.ImageRef(), pins)
+	}
+	created, ok := conn.ContainerConfig(constants.EgressProxyContainer)
+	if !ok {
+		t.Fatal("created proxy has no config")
+	}
+	if created.ImageDigest != digest {
+		t.Errorf("proxy created from digest %q, want pinned %q", created.ImageDigest, digest)
+	}
+
+	// unused by any app, the pin of the proxy is still kept
+	used, err := dropUnusedPins(nil, pins)
+	if err != nil {
+		t.Fatalf("dropUnusedPins: %v", err)
+	}
+	if used[proxyCfg.ImageRef()] != digest {
+		t.Errorf("dropUnusedPins() = %v, dropped the proxy pin", used)
+	}
+}
+
+func TestStartEgressProxyJoinsProxyNetworks(t *testing.T) {
+	useTempWorkspace(t)
+	conn := newTestManager(t)
+	registerAllowlistedApp(t, "rocket-egress-a")
+
+	if _, err := startEgressProxy(t.Context(), conn, "rocket-egress-a"); err != nil {
+		t.Fatalf("startEgressProxy: %v", err)
+	}
+	registerAllowlistedApp(t, "rocket-egress-b")
+	if _, err := startEgressProxy(t.Context(), conn, "rocket-egress-b"); err != nil {
+		t.Fatalf("startEgressProxy: %v", err)
+	}
+
+	status, err := conn.InspectContainer(t.Context(), constants.EgressProxyContainer)
+	if err != nil {
+		t.Fatalf("InspectContainer: %v", err)
+	}
+	want := []string{
+		constants.EgressNetwork,
+		containers.EgressProxyNetwork("rocket-egress-a"),
+		containers.EgressProxyNetwork("rocket-egress-b"),
+	}
+	slices.Sort(want)
+	if !slices.Equal(status.Networks, want) {
+		t.Errorf("proxy networks = %v, want %v", status.Networks, want)
+	}
+	if !status.Running() {
+		t.Errorf("proxy state = %q, want running", status.State)
+	}
+}
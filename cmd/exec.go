@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"context"
+	"log/slog"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"golang.org/x/term"
+
+	"ayayushsharma/rocket/common"
+	"ayayushsharma/rocket/containers"
+)
+
+var execCmd = &cobra.Command{
+	Use:   "exec [container-name] -- [command]",
+	Short: "Runs a command inside a running rocket application",
+	Args:  cobra.MinimumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
+		ctx := cmd.Context()
+		conn, err := containerManager()
+		if err != nil {
+			slog.Debug("Failed to connect to container runtime. Exiting")
+			return
+		}
+
+		exitCode, err := execInApp(
+			ctx,
+			conn,
+			common.CompleteAppName(args[0]),
+			args[1:],
+			viper.GetBool("interactive"),
+			viper.GetBool("tty"),
+		)
+		if err != nil {
+			return
+		}
+		if exitCode != 0 {
+			os.Exit(exitCode)
+		}
+		return nil
+	},
+	ValidArgsFunction: stopAppCompletionFn,
+}
+
+var shellCmd = &cobra.Command{
+	Use:   "shell [container-name]",
+	Short: "Opens an interactive shell inside a running rocket application",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
+		ctx := cmd.Context()
+		conn, err := containerManager()
+		if err != nil {
+			slog.Debug("Failed to connect to container runtime. Exiting")
+			return
+		}
+
+		exitCode, err := execInApp(
+			ctx,
+			conn,
+			common.CompleteAppName(args[0]),
+			[]string{viper.GetString("shell")},
+			true,
+			true,
+		)
+		if err != nil {
+			return
+		}
+		if exitCode != 0 {
+			os.Exit(exitCode)
+		}
+		return nil
+	},
+	ValidArgsFunction: stopAppCompletionFn,
+}
+
+func init() {
+	rootCmd.AddCommand(execCmd)
+	execCmd.Flags().BoolP("interactive", "i", false, "Keep STDIN attached to the command")
+	execCmd.Flags().BoolP("tty", "t", false, "Allocate a pseudo-TTY for the command")
+
+	rootCmd.AddCommand(shellCmd)
+	shellCmd.Flags().String("shell", "/bin/sh", "Shell to start inside the container")
+}
+
+// Runs the command in app container with stdio of rocket attached.
+// Terminal is switched to raw mode for the duration of TTY sessions
+func execInApp(
+	ctx context.Context,
+	conn containers.ContainerManager,
+	appName string,
+	command []string,
+	interactive bool,
+	tty bool,
+) (exitCode int, err error) {
+	slog.Debug("Executing in... "+appName, "command", command)
+
+	stdinFd := int(os.Stdin.Fd())
+	if tty && interactive && term.IsTerminal(stdinFd) {
+		state, err := term.MakeRaw(stdinFd)
+		if err != nil {
+			return -1, err
+		}
+		defer term.Restore(stdinFd, state)
+	}
+
+	exitCode, err = conn.Exec(ctx, appName, containers.ExecOptions{
+		Cmd:         command,
+		Tty:         tty,
+		Interactive: interactive,
+		Stdin:       os.Stdin,
+		Stdout:      os.Stdout,
+		Stderr:      os.Stderr,
+	})
+	if err != nil {
+		slog.Debug("Failed to exec in application", "application", appName, "error", err)
+		return -1, err
+	}
+
+	return exitCode, nil
+}
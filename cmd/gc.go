@@ -0,0 +1,254 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"ayayushsharma/rocket/common"
+	"ayayushsharma/rocket/containers"
+	"ayayushsharma/rocket/workspace"
+)
+
+type garbageImage struct {
+	name string
+	size int64
+}
+
+// Objects rocket created that no registered app uses anymore
+type garbage struct {
+	images     []garbageImage
+	volumes    []string
+	containers []string
+	pods       []string
+
+	// recorded images that are already gone from the runtime
+	staleImages []string
+}
+
+var gcCmd = &cobra.Command{
+	Use:   "gc",
+	Short: "Removes images, volumes, containers and pods no registered app uses",
+	Long: `Removes what rocket created that no registered app uses anymore.
+
+Images pulled by rocket that are not used by a registered app, the router or
+the egress proxy, volumes created for apps that are no longer registered,
+stopped containers of such apps and the pods of their stacks are removed.
+Use --dry-run to only list them.`,
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
+		ctx := cmd.Context()
+		conn, err := containerManager()
+		if err != nil {
+			return
+		}
+
+		apps, err := workspace.GetApps()
+		if err != nil {
+			return
+		}
+
+		found, err := collectGarbage(ctx, conn, apps)
+		if err != nil {
+			return
+		}
+
+		if viper.GetBool("dry-run") {
+			found.print("Would remove", "Reclaimable image space")
+			return nil
+		}
+		return found.remove(ctx, conn)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(gcCmd)
+	gcCmd.Flags().Bool(
+		"dry-run", false, "List what would be removed and the space it takes",
+	)
+}
+
+func collectGarbage(
+	ctx context.Context,
+	conn containers.ContainerManager,
+	apps map[string]containers.Config,
+) (found garbage, err error) {
+	pins, err := workspace.GetPins()
+	if err != nil {
+		return found, err
+	}
+
+	// apps use their images by tag and by pinned digest alike
+	referenced := []string{routerImage}
+	for _, appCfg := range append(slices.Collect(maps.Values(apps)), egressProxyConfig()) {
+		referenced = append(referenced, appCfg.ImageRefs()...)
+		referenced = append(referenced, appCfg.Pin(pins).Images()...)
+	}
+
+	pulled, err := workspace.PulledImages()
+	if err != nil {
+		return found, err
+	}
+	for _, image := range pulled {
+		if slices.Contains(referenced, image) {
+			continue
+		}
+		details, err := conn.InspectImage(ctx, image)
+		if errors.Is(err, containers.ImageDoesntExistErr) {
+			found.staleImages = append(found.staleImages, image)
+			continue
+		}
+		if err != nil {
+			return found, err
+		}
+		found.images = append(
+			found.images, garbageImage{name: image, size: details.Size},
+		)
+	}
+
+	managed, err := conn.ListManagedContainers(ctx)
+	if err != nil {
+		return found, err
+	}
+
+	// apps keeping containers after gc still use their volumes
+	remaining := map[string]bool{}
+	for _, container := range managed {
+		_, registered := apps[container.App()]
+		orphan := !registered && !slices.Contains(systemContainers, container.App())
+		if orphan && !container.Running() {
+			found.containers = append(found.containers, container.Name)
+			continue
+		}
+		remaining[container.App()] = true
+	}
+
+	pods, err := conn.ListManagedPods(ctx)
+	if err != nil && !errors.Is(err, containers.PodsUnsupportedErr) {
+		return found, err
+	}
+	for _, pod := range pods {
+		_, registered := apps[pod.App()]
+		if !registered && !remaining[pod.App()] {
+			found.pods = append(found.pods, pod.Name)
+		}
+	}
+
+	volumes, err := conn.ListManagedVolumes(ctx)
+	if err != nil {
+		return found, err
+	}
+	for _, volume := range volumes {
+		_, registered := apps[volume.App()]
+		if !registered && !remaining[volume.App()] {
+			found.volumes = append(found.volumes, volume.Name)
+		}
+	}
+
+	slices.SortFunc(found.images, func(a, b garbageImage) int {
+		return strings.Compare(a.name, b.name)
+	})
+	slices.Sort(found.volumes)
+	slices.Sort(found.containers)
+	slices.Sort(found.pods)
+	return found, nil
+}
+
+func (g garbage) empty() bool {
+	return len(g.images)+len(g.volumes)+len(g.containers)+len(g.pods) == 0
+}
+
+func (g garbage) print(title string, spaceTitle string) {
+	if g.empty() {
+		fmt.Println("Nothing to remove")
+		return
+	}
+
+	fmt.Printf("%s:\n", title)
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	var reclaimable int64
+	for _, image := range g.images {
+		fmt.Fprintf(writer, "  image\t%s\t%s\n", image.name, humanSize(image.size))
+		reclaimable += image.size
+	}
+	for _, volume := range g.volumes {
+		fmt.Fprintf(writer, "  volume\t%s\t\n", volume)
+	}
+	for _, container := range g.containers {
+		fmt.Fprintf(writer, "  container\t%s\t\n", common.ShortenAppName(container))
+	}
+	for _, pod := range g.pods {
+		fmt.Fprintf(writer, "  pod\t%s\t\n", common.ShortenAppName(pod))
+	}
+	writer.Flush()
+
+	fmt.Printf("%s: %s\n", spaceTitle, humanSize(reclaimable))
+}
+
+// Removes containers and then their pods first, so that volumes and images
+// are free to go
+func (g garbage) remove(
+	ctx context.Context,
+	conn containers.ContainerManager,
+) (err error) {
+	removed := garbage{}
+	failed := 0
+
+	for _, container := range g.containers {
+		if err := conn.RemoveContainer(ctx, container, false); err != nil {
+			slog.Debug("Failed to remove container", "name", container, "error", err)
+			failed++
+			continue
+		}
+		removed.containers = append(removed.containers, container)
+	}
+
+	for _, pod := range g.pods {
+		if err := conn.RemovePod(ctx, pod, false); err != nil {
+			slog.Debug("Failed to remove pod", "name", pod, "error", err)
+			failed++
+			continue
+		}
+		removed.pods = append(removed.pods, pod)
+	}
+
+	for _, volume := range g.volumes {
+		if err := conn.RemoveVolume(ctx, volume, false); err != nil {
+			slog.Debug("Failed to remove volume", "name", volume, "error", err)
+			failed++
+			continue
+		}
+		removed.volumes = append(removed.volumes, volume)
+	}
+
+	forget := g.staleImages
+	for _, image := range g.images {
+		if err := conn.RemoveImage(ctx, image.name); err != nil {
+			slog.Debug("Failed to remove image", "name", image.name, "error", err)
+			failed++
+			continue
+		}
+		removed.images = append(removed.images, image)
+		forget = append(forget, image.name)
+	}
+
+	if len(forget) > 0 {
+		if err = workspace.ForgetPulledImages(forget); err != nil {
+			return err
+		}
+	}
+
+	removed.print("Removed", "Reclaimed image space")
+	if failed > 0 {
+		return fmt.Errorf("failed to remove %d object(s)", failed)
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"slices"
+	"testing"
+
+	"ayayushsharma/rocket/containers"
+	"ayayushsharma/rocket/workspace"
+)
+
+func TestCollectGarbageRemovesOrphanedPod(t *testing.T) {
+	useTempWorkspace(t)
+	conn := newTestManager(t)
+	appCfg := registerTestApp(t, "rocket-gc-stack", containers.Config{
+		ImageURL:      "docker.io/library/redis",
+		ImageVersion:  "alpine",
+		ContainerName: "redis",
+	})
+	if err := createApp(t.Context(), conn, appCfg); err != nil {
+		t.Fatalf("createApp: %v", err)
+	}
+	if err := workspace.Unregister(appCfg.ContainerName); err != nil {
+		t.Fatal(err)
+	}
+
+	apps, err := workspace.GetApps()
+	if err != nil {
+		t.Fatal(err)
+	}
+	found, err := collectGarbage(t.Context(), conn, apps)
+	if err != nil {
+		t.Fatalf("collectGarbage: %v", err)
+	}
+	if !slices.Equal(found.pods, []string{appCfg.PodName()}) {
+		t.Errorf("pods = %v, want [%s]", found.pods, appCfg.PodName())
+	}
+	if err = found.remove(t.Context(), conn); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+
+	exists, err := conn.PodExists(t.Context(), appCfg.PodName())
+	if err != nil || exists {
+		t.Errorf("PodExists = %v, %v, want false", exists, err)
+	}
+}
@@ -1,21 +1,134 @@
-//go:build !production
 package cmd
 
 import (
+	"context"
+	"fmt"
+	"log/slog"
 	"os"
+	"text/tabwriter"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"ayayushsharma/rocket/common"
+	"ayayushsharma/rocket/constants"
+	"ayayushsharma/rocket/containers"
+	"ayayushsharma/rocket/workspace"
 )
 
 var healthCmd = &cobra.Command{
 	Use:   "health",
-	Short: "Checking health of application contaienrs !TODO",
+	Short: "Checks health of the router, network and application containers",
+	Long: "Reports health of the router, the rocket network and every registered\n" +
+		"application. Exits with a non-zero code when anything is unhealthy",
+
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
+		ctx := cmd.Context()
+		conn, err := containerManager()
+		if err != nil {
+			slog.Debug("Failed to connect to container runtime. Exiting")
+			return
+		}
 
-	Run: func(cmd *cobra.Command, args []string) {
-		os.Exit(0)
+		healthy, err := checkHealth(ctx, conn)
+		if err != nil {
+			return
+		}
+
+		if !healthy {
+			os.Exit(1)
+		}
+		return nil
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(healthCmd)
 }
+
+// Prints health report and returns whether everything is healthy
+func checkHealth(
+	ctx context.Context,
+	conn containers.ContainerManager,
+) (healthy bool, err error) {
+	healthy = true
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "COMPONENT\tNAME\tHEALTH")
+
+	networkName := viper.GetString("routes.network")
+	networkExists, err := conn.NetworkExists(ctx, networkName)
+	if err != nil {
+		return false, err
+	}
+	networkHealth := "present"
+	if !networkExists {
+		networkHealth = "missing"
+		healthy = false
+	}
+	fmt.Fprintf(writer, "network\t%s\t%s\n", networkName, networkHealth)
+
+	statuses, err := appStatuses(ctx, conn)
+	if err != nil {
+		return false, err
+	}
+	apps, err := workspace.GetApps()
+	if err != nil {
+		return false, err
+	}
+
+	for _, app := range statuses {
+		component := "app"
+		if app.ContainerName == constants.RouterContainer {
+			component = "router"
+		}
+		// apps that were never launched are not expected to run
+		if component == "app" && app.Status.State == stateNotCreated {
+			fmt.Fprintf(writer, "app\t%s\tnot running\n", common.ShortenAppName(app.ContainerName))
+			continue
+		}
+		if !app.Status.Healthy() {
+			healthy = false
+		}
+		fmt.Fprintf(
+			writer,
+			"%s\t%s\t%s\n",
+			component,
+			common.ShortenAppName(app.ContainerName),
+			healthDescription(app.Status),
+		)
+
+		appCfg, ok := apps[app.ContainerName]
+		if !ok || !appCfg.IsStack() {
+			continue
+		}
+		// web container of the stack is the app itself
+		for _, member := range appCfg.StackContainers()[1:] {
+			status, err := containerStatus(ctx, conn, member.ContainerName)
+			if err != nil {
+				return false, err
+			}
+			if !status.Healthy() {
+				healthy = false
+			}
+			fmt.Fprintf(
+				writer,
+				"stack\t%s\t%s\n",
+				common.ShortenAppName(member.ContainerName),
+				healthDescription(status),
+			)
+		}
+	}
+
+	return healthy, writer.Flush()
+}
+
+func healthDescription(status containers.ContainerStatus) string {
+	if !status.Running() {
+		return status.State
+	}
+	if status.Health == "" {
+		return "running"
+	}
+	return status.Health
+}
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"testing"
+
+	"ayayushsharma/rocket/containers"
+)
+
+func TestCheckHealthIgnoresAppsNotCreated(t *testing.T) {
+	useTempWorkspace(t)
+	conn := newTestManager(t)
+	if err := startRouter(t.Context(), conn); err != nil {
+		t.Fatalf("startRouter: %v", err)
+	}
+	registerTestApp(t, "rocket-idle")
+
+	healthy, err := checkHealth(t.Context(), conn)
+	if err != nil {
+		t.Fatalf("checkHealth: %v", err)
+	}
+	if !healthy {
+		t.Error("app that was never launched reported as unhealthy")
+	}
+}
+
+func TestCheckHealthOfStackContainers(t *testing.T) {
+	useTempWorkspace(t)
+	conn := newTestManager(t)
+	if err := startRouter(t.Context(), conn); err != nil {
+		t.Fatalf("startRouter: %v", err)
+	}
+	appCfg := registerTestApp(t, "rocket-stacked", containers.Config{
+		ImageURL:      "docker.io/library/redis",
+		ImageVersion:  "alpine",
+		ContainerName: "redis",
+	})
+	if err := launchApp(t.Context(), conn, appCfg.ContainerName); err != nil {
+		t.Fatalf("launchApp: %v", err)
+	}
+
+	healthy, err := checkHealth(t.Context(), conn)
+	if err != nil {
+		t.Fatalf("checkHealth: %v", err)
+	}
+	if !healthy {
+		t.Fatal("running stack reported as unhealthy")
+	}
+
+	member := appCfg.StackContainers()[1].ContainerName
+	if err = conn.SetHealth(member, containers.HealthUnhealthy); err != nil {
+		t.Fatal(err)
+	}
+	healthy, err = checkHealth(t.Context(), conn)
+	if err != nil {
+		t.Fatalf("checkHealth: %v", err)
+	}
+	if healthy {
+		t.Errorf("stack with unhealthy %s reported as healthy", member)
+	}
+}
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+
+	"ayayushsharma/rocket/constants"
+	"ayayushsharma/rocket/containers"
+	"ayayushsharma/rocket/workspace"
+)
+
+const testNetwork = "rocket-test"
+
+// Points the workspace, lock file and state of rocket at a temporary
+// directory and sets the config commands read, for the duration of the test
+func useTempWorkspace(t *testing.T) {
+	t.Helper()
+
+	dir := t.TempDir()
+	paths := map[*string]string{
+		&constants.AppStateDir:       filepath.Join(dir, "state"),
+		&constants.NginxConfPath:     filepath.Join(dir, "state", "nginx.conf"),
+		&constants.HomePageDir:       filepath.Join(dir, "state", "home-page"),
+		&constants.RoutesJson:        filepath.Join(dir, "application.json"),
+		&constants.EgressProxyConf:   filepath.Join(dir, "state", "squid.conf"),
+		&constants.EgressPortsJson:   filepath.Join(dir, "state", "ports.json"),
+		&constants.WorkspaceAppsJson: filepath.Join(dir, "workspace.rockets.json"),
+		&constants.WorkspaceLockJson: filepath.Join(dir, "rockets.lock"),
+		&constants.TrustPolicyJson:   filepath.Join(dir, "policy.json"),
+		&constants.SystemdUserDir:    filepath.Join(dir, "systemd"),
+		&constants.BackupDir:         filepath.Join(dir, "backups"),
+	}
+	for path, value := range paths {
+		previous := *path
+		*path = value
+		t.Cleanup(func() { *path = previous })
+	}
+
+	setConfig(t, "routes.network", testNetwork)
+	setConfig(t, "quiet", true)
+	setConfig(t, "force", false)
+}
+
+func setConfig(t *testing.T, key string, value any) {
+	t.Helper()
+
+	previous := viper.Get(key)
+	viper.Set(key, value)
+	t.Cleanup(func() { viper.Set(key, previous) })
+}
+
+// Busybox app on the test network
+func testApp(name string, stack ...containers.Config) containers.Config {
+	return containers.Config{
+		ImageURL:        "docker.io/library/busybox",
+		ImageVersion:    "latest",
+		ContainerName:   name,
+		ApplicationName: name,
+		SubDomain:       name + ".app.localhost",
+		NetworkName:     testNetwork,
+		Stack:           stack,
+	}
+}
+
+// Registers a busybox app on the test network
+func registerTestApp(t *testing.T, name string, stack ...containers.Config) containers.Config {
+	t.Helper()
+
+	appCfg := testApp(name, stack...)
+	if err := workspace.Register(appCfg); err != nil {
+		t.Fatalf("Register(%q): %v", name, err)
+	}
+	return appCfg
+}
+
+// Memory manager with the test network apps join
+func newTestManager(t *testing.T) *containers.MemoryManager {
+	t.Helper()
+
+	conn := containers.NewMemoryManager()
+	if err := conn.CreateNetwork(t.Context(), testNetwork, true); err != nil {
+		t.Fatalf("CreateNetwork: %v", err)
+	}
+	return conn
+}
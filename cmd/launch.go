@@ -1,16 +1,19 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
-	"os"
 	"slices"
+	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 
 	"ayayushsharma/rocket/common"
+	"ayayushsharma/rocket/constants"
 	"ayayushsharma/rocket/containers"
+	"ayayushsharma/rocket/trust"
 	"ayayushsharma/rocket/workspace"
 )
 
@@ -19,7 +22,8 @@ var launchCmd = &cobra.Command{
 	Short: "Launch locally registered application",
 	Args:  cobra.MinimumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) (err error) {
-		conn, err := containers.Manager()
+		ctx := cmd.Context()
+		conn, err := containerManager()
 		if err != nil {
 			slog.Debug("Failed to connect to podman. Exiting")
 			return
@@ -27,17 +31,18 @@ var launchCmd = &cobra.Command{
 
 		isLaunchAll := viper.GetBool("all")
 		if isLaunchAll {
-			launchAll(conn)
-			return
+			return launchAll(ctx, conn)
 		}
 
-		if len(args) > 0 {
-			for _, appName := range args {
-				launchApp(conn, common.CompleteAppName(appName))
+		var storeErr error
+		for _, appName := range args {
+			err = launchApp(ctx, conn, common.CompleteAppName(appName))
+			if err != nil {
+				storeErr = err
 			}
 		}
 
-		return nil
+		return storeErr
 	},
 	ValidArgsFunction: launchAppCompletionFn,
 }
@@ -45,57 +50,116 @@ var launchCmd = &cobra.Command{
 func init() {
 	rootCmd.AddCommand(launchCmd)
 	launchCmd.Flags().Bool("all", false, "Launch all the registered apps")
+	launchCmd.Flags().BoolP("quiet", "q", false, "Do not show image pull progress")
 }
 
-func launchApp(conn containers.ContainerManager, appName string) (err error) {
+func launchApp(
+	ctx context.Context,
+	conn containers.ContainerManager,
+	appName string,
+) (err error) {
 	slog.Debug("Launching... " + appName)
 
-	exists, err := conn.ContainerExists(appName)
+	appCfg, err := workspace.GetAppCfg(appName)
+	if err != nil {
+		if err == workspace.AppNotRegisteredErr {
+			fmt.Printf("App not registered: %s", appName)
+		}
+		return err
+	}
+
+	exists, err := appExists(ctx, conn, appCfg)
+	if err != nil {
+		slog.Debug("Failed to check if container exists", "error", err)
+		return err
+	}
 
 	if !exists {
-		if err = createApp(conn, appName); err != nil {
+		if err = createApp(ctx, conn, appCfg); err != nil {
 			slog.Debug("Failed to create app", "error", err)
 			return err
 		}
 	}
 
-	err = conn.StartService(appName)
+	err = startApp(ctx, conn, appCfg)
 	if err != nil {
-		slog.Debug("Failed to start container. Exiting")
-		os.Exit(1)
+		slog.Debug("Failed to start container", "error", err)
+		return err
 	}
 	slog.Debug("Successfully started application", "application", appName)
 
 	return nil
 }
 
-func createApp(conn containers.ContainerManager, appName string) (err error) {
-	appCfg, err := workspace.GetAppCfg(appName)
-	if err != nil {
-		if err == workspace.AppNotRegisteredErr {
-			fmt.Printf("App not registered: %s", appName)
-		}
-		return err
+// Apps with a stack exist once their pod exists
+func appExists(
+	ctx context.Context,
+	conn containers.ContainerManager,
+	appCfg containers.Config,
+) (exists bool, err error) {
+	if appCfg.IsStack() {
+		return conn.PodExists(ctx, appCfg.PodName())
 	}
+	return conn.ContainerExists(ctx, appCfg.ContainerName)
+}
 
-	image := common.ImageWithVersion(appCfg.ImageURL, appCfg.ImageVersion)
-	exists, err := conn.ImageExists(image)
+func startApp(
+	ctx context.Context,
+	conn containers.ContainerManager,
+	appCfg containers.Config,
+) error {
+	if appCfg.IsStack() {
+		return containers.StartStack(ctx, conn, appCfg)
+	}
+	return conn.StartService(ctx, appCfg.ContainerName)
+}
 
+func createApp(
+	ctx context.Context,
+	conn containers.ContainerManager,
+	appCfg containers.Config,
+) (err error) {
+	// images are created from the digests of rockets.lock, pinning them
+	// first if the app was never launched
+	appCfg, err = pinApp(ctx, conn, appCfg, false)
 	if err != nil {
-		slog.Debug("App image could not be checked if it exists", "error", err)
+		slog.Debug("App images could not be pinned", "error", err)
 		return err
 	}
 
-	if !exists {
-		slog.Debug("Image name", "image", image)
-		err := conn.PullImage(image)
+	for _, image := range appCfg.Images() {
+		exists, err := conn.ImageExists(ctx, image)
 		if err != nil {
-			slog.Debug("App image could not be pulled", "error", err)
+			slog.Debug("App image could not be checked if it exists", "error", err)
 			return err
 		}
+
+		if !exists {
+			slog.Debug("Image name", "image", image)
+			err := pullImage(ctx, conn, image)
+			if err != nil {
+				slog.Debug("App image could not be pulled", "error", err)
+				return err
+			}
+		}
+	}
+
+	if err = verifyApp(ctx, appCfg); err != nil {
+		slog.Debug("App images failed verification", "error", err)
+		return err
+	}
+
+	appCfg, err = prepareEgress(ctx, conn, appCfg)
+	if err != nil {
+		slog.Debug("Egress of app could not be prepared", "error", err)
+		return err
 	}
 
-	err = conn.CreateContainer(appCfg)
+	if appCfg.IsStack() {
+		err = containers.CreateStack(ctx, conn, appCfg)
+	} else {
+		err = conn.CreateContainer(ctx, appCfg)
+	}
 	if err != nil {
 		slog.Error("Failed to create App container", "error", err)
 		return err
@@ -104,7 +168,30 @@ func createApp(conn containers.ContainerManager, appName string) (err error) {
 	return nil
 }
 
-func launchAll(conn containers.ContainerManager) (err error) {
+// Refuses images of the app and its stack that do not satisfy the trust
+// policy, unless `insecure-skip-verify` is set. Stack containers without a
+// key of their own are verified with the key of the app
+func verifyApp(ctx context.Context, appCfg containers.Config) error {
+	if viper.GetBool("insecure-skip-verify") {
+		slog.Debug("Skipping image verification", "app", appCfg.ContainerName)
+		return nil
+	}
+
+	members := append([]containers.Config{appCfg}, appCfg.Stack...)
+	for _, member := range members {
+		publicKey := member.SignatureKey
+		if publicKey == "" {
+			publicKey = appCfg.SignatureKey
+		}
+		err := trust.Verify(ctx, member.Image(), publicKey, constants.TrustPolicyJson)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func launchAll(ctx context.Context, conn containers.ContainerManager) (err error) {
 	apps, err := workspace.GetApps()
 	if err != nil {
 		return err
@@ -112,7 +199,11 @@ func launchAll(conn containers.ContainerManager) (err error) {
 
 	var storeErr error
 	for appName := range apps {
-		err = launchApp(conn, appName)
+		// remaining apps would fail the same way once interrupted
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		err = launchApp(ctx, conn, appName)
 		if err != nil {
 			storeErr = err
 		}
@@ -121,7 +212,7 @@ func launchAll(conn containers.ContainerManager) (err error) {
 	return storeErr
 }
 
-func launchAppCompletionFn(_ *cobra.Command, _ []string, toComplete string) (
+func launchAppCompletionFn(cmd *cobra.Command, _ []string, toComplete string) (
 	completion []cobra.Completion,
 	shellDirective cobra.ShellCompDirective,
 ) {
@@ -129,35 +220,37 @@ func launchAppCompletionFn(_ *cobra.Command, _ []string, toComplete string) (
 	runningRockets := []string{}
 	var conn containers.ContainerManager
 
-	conn, err := containers.Manager()
+	ctx := cmd.Context()
+	conn, err := containerManager()
 	if err != nil {
 		return
 	}
 
-	runningApps, err := conn.ListContainers()
+	workspaceApps, err := workspace.GetApps()
 	if err != nil {
 		return
 	}
 
-	workspaceApps, err := workspace.GetApps()
+	runningApps, err := runningAppNames(ctx, conn, workspaceApps)
 	if err != nil {
 		return
 	}
 
 	for workspaceApp := range workspaceApps {
-		if !slices.Contains(runningApps, workspaceApp) {
+		if !runningApps[workspaceApp] {
 			runningRockets = append(
 				runningRockets, common.ShortenAppName(workspaceApp),
 			)
 		}
 	}
+	slices.Sort(runningRockets)
 
 	if len(toComplete) == 0 {
 		return runningRockets, shellDirective
 	}
 
 	for _, rocketApp := range runningRockets {
-		if toComplete == rocketApp[:len(toComplete)] {
+		if strings.HasPrefix(rocketApp, toComplete) {
 			completion = append(completion, rocketApp)
 		}
 	}
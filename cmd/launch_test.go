@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"errors"
+	"testing"
+
+	"ayayushsharma/rocket/containers"
+	"ayayushsharma/rocket/workspace"
+)
+
+func TestCreateAppPinsAndCreates(t *testing.T) {
+	useTempWorkspace(t)
+	conn := newTestManager(t)
+	appCfg := registerTestApp(t, "rocket-create")
+
+	if err := createApp(t.Context(), conn, appCfg); err != nil {
+		t.Fatalf("createApp: %v", err)
+	}
+
+	if state := conn.ContainerState("rocket-create"); state != containers.MemoryStateCreated {
+		t.Errorf("container state = %q, want %q", state, containers.MemoryStateCreated)
+	}
+	pins, err := workspace.GetPins()
+	if err != nil {
+		t.Fatalf("GetPins: %v", err)
+	}
+	digest, ok := pins[appCfg.ImageRef()]
+	if !ok {
+		t.Fatalf("image %q not pinned, pins = %v", appCfg.ImageRef(), pins)
+	}
+	created, ok := conn.ContainerConfig("rocket-create")
+	if !ok {
+		t.Fatal("created container has no config")
+	}
+	if created.ImageDigest != digest {
+		t.Errorf("container created from digest %q, want pinned %q", created.ImageDigest, digest)
+	}
+}
+
+func TestLaunchAppStartsApp(t *testing.T) {
+	useTempWorkspace(t)
+	conn := newTestManager(t)
+	registerTestApp(t, "rocket-launch")
+
+	if err := launchApp(t.Context(), conn, "rocket-launch"); err != nil {
+		t.Fatalf("launchApp: %v", err)
+	}
+	if state := conn.ContainerState("rocket-launch"); state != containers.MemoryStateRunning {
+		t.Errorf("container state = %q, want %q", state, containers.MemoryStateRunning)
+	}
+
+	// launching again starts the existing container
+	if err := launchApp(t.Context(), conn, "rocket-launch"); err != nil {
+		t.Fatalf("second launchApp: %v", err)
+	}
+}
+
+func TestLaunchAppStack(t *testing.T) {
+	useTempWorkspace(t)
+	conn := newTestManager(t)
+	appCfg := registerTestApp(t, "rocket-stack", containers.Config{
+		ImageURL:      "docker.io/library/redis",
+		ImageVersion:  "alpine",
+		ContainerName: "rocket-stack-redis",
+	})
+
+	if err := launchApp(t.Context(), conn, "rocket-stack"); err != nil {
+		t.Fatalf("launchApp: %v", err)
+	}
+
+	exists, err := conn.PodExists(t.Context(), appCfg.PodName())
+	if err != nil || !exists {
+		t.Fatalf("PodExists(%q) = %v, %v, want true", appCfg.PodName(), exists, err)
+	}
+	for _, member := range appCfg.StackContainers() {
+		state := conn.ContainerState(member.ContainerName)
+		if state != containers.MemoryStateRunning {
+			t.Errorf("%s state = %q, want %q", member.ContainerName, state, containers.MemoryStateRunning)
+		}
+	}
+}
+
+func TestLaunchAppNotRegistered(t *testing.T) {
+	useTempWorkspace(t)
+	conn := newTestManager(t)
+
+	err := launchApp(t.Context(), conn, "rocket-missing")
+	if !errors.Is(err, workspace.AppNotRegisteredErr) {
+		t.Fatalf("launchApp error = %v, want %v", err, workspace.AppNotRegisteredErr)
+	}
+}
+
+func TestLaunchAll(t *testing.T) {
+	useTempWorkspace(t)
+	conn := newTestManager(t)
+	registerTestApp(t, "rocket-first")
+	registerTestApp(t, "rocket-second")
+
+	if err := launchAll(t.Context(), conn); err != nil {
+		t.Fatalf("launchAll: %v", err)
+	}
+	for _, name := range []string{"rocket-first", "rocket-second"} {
+		if state := conn.ContainerState(name); state != containers.MemoryStateRunning {
+			t.Errorf("%s state = %q, want %q", name, state, containers.MemoryStateRunning)
+		}
+	}
+}
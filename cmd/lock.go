@@ -0,0 +1,184 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"slices"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"ayayushsharma/rocket/common"
+	"ayayushsharma/rocket/containers"
+	"ayayushsharma/rocket/workspace"
+)
+
+var lockCmd = &cobra.Command{
+	Use:   "lock [container-name]",
+	Short: "Pins images of registered apps to digests in rockets.lock",
+	Long: `Pins images of registered apps to the digests their versions point to.
+
+Images are pinned on first launch and stay pinned until the pins are
+refreshed with --update. Without app names every registered app and the egress
+proxy are locked and pins no app uses anymore are dropped. Run update afterwards to recreate apps
+from the newly pinned images.`,
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
+		ctx := cmd.Context()
+		conn, err := containerManager()
+		if err != nil {
+			return
+		}
+
+		apps, err := workspace.GetApps()
+		if err != nil {
+			return
+		}
+
+		appNames := []string{}
+		for _, appName := range args {
+			appName = common.CompleteAppName(appName)
+			if _, ok := apps[appName]; !ok {
+				return fmt.Errorf("%w: %s", workspace.AppNotRegisteredErr, appName)
+			}
+			appNames = append(appNames, appName)
+		}
+		lockAll := len(appNames) == 0
+		if lockAll {
+			appNames = slices.Sorted(maps.Keys(apps))
+		}
+
+		update := viper.GetBool("update")
+		for _, appName := range appNames {
+			if _, err = pinApp(ctx, conn, apps[appName], update); err != nil {
+				return fmt.Errorf("lock %s: %w", appName, err)
+			}
+		}
+		if lockAll && update {
+			if err = refreshEgressProxyPin(ctx, conn); err != nil {
+				return fmt.Errorf("lock egress proxy: %w", err)
+			}
+		}
+
+		pins, err := workspace.GetPins()
+		if err != nil {
+			return
+		}
+		if lockAll {
+			pins, err = dropUnusedPins(apps, pins)
+			if err != nil {
+				return
+			}
+		}
+
+		writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(writer, "IMAGE\tDIGEST")
+		for _, ref := range slices.Sorted(maps.Keys(pins)) {
+			fmt.Fprintf(writer, "%s\t%s\n", ref, pins[ref])
+		}
+		return writer.Flush()
+	},
+	ValidArgsFunction: unregisterAppCompletionFn,
+}
+
+func init() {
+	rootCmd.AddCommand(lockCmd)
+	lockCmd.Flags().Bool(
+		"update", false, "Resolve pinned images again and refresh their digests",
+	)
+	lockCmd.Flags().BoolP("quiet", "q", false, "Do not show image pull progress")
+}
+
+// Resolves images of the app to digests and records them in the lock file.
+// Already pinned images keep their digest unless update is set. Digests are
+// only recorded once the images they point to pass verification. Returns the
+// config pinned to the digests
+func pinApp(
+	ctx context.Context,
+	conn containers.ContainerManager,
+	appCfg containers.Config,
+	update bool,
+) (pinned containers.Config, err error) {
+	pins, err := workspace.GetPins()
+	if err != nil {
+		return pinned, err
+	}
+
+	resolved := false
+	for _, ref := range appCfg.ImageRefs() {
+		if _, ok := pins[ref]; ok && !update {
+			continue
+		}
+
+		digest, err := resolveDigest(ctx, conn, ref, update)
+		if err != nil {
+			return pinned, err
+		}
+		slog.Debug("Pinned image", "image", ref, "digest", digest)
+		pins[ref] = digest
+		resolved = true
+	}
+
+	if resolved {
+		if err = verifyApp(ctx, appCfg.Pin(pins)); err != nil {
+			return pinned, err
+		}
+		if err = workspace.SavePins(pins); err != nil {
+			return pinned, err
+		}
+	}
+
+	return appCfg.Pin(pins), nil
+}
+
+// Digest the image reference points to. Images present locally are resolved
+// without pulling, unless refresh is set
+func resolveDigest(
+	ctx context.Context,
+	conn containers.ContainerManager,
+	ref string,
+	refresh bool,
+) (digest string, err error) {
+	exists, err := conn.ImageExists(ctx, ref)
+	if err != nil {
+		return "", err
+	}
+	if !exists || refresh {
+		if err = pullImage(ctx, conn, ref); err != nil {
+			return "", err
+		}
+	}
+
+	details, err := conn.InspectImage(ctx, ref)
+	if err != nil {
+		return "", err
+	}
+	if details.Digest == "" {
+		return "", fmt.Errorf("runtime reported no digest for %s", ref)
+	}
+	return details.Digest, nil
+}
+
+// Drops pins of images no registered app uses
+func dropUnusedPins(
+	apps map[string]containers.Config,
+	pins map[string]string,
+) (used map[string]string, err error) {
+	used = map[string]string{}
+	// the egress proxy is pinned like the apps it serves
+	for _, appCfg := range append(slices.Collect(maps.Values(apps)), egressProxyConfig()) {
+		for _, ref := range appCfg.ImageRefs() {
+			if digest, ok := pins[ref]; ok {
+				used[ref] = digest
+			}
+		}
+	}
+
+	if len(used) == len(pins) {
+		return pins, nil
+	}
+	return used, workspace.SavePins(used)
+}
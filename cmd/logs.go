@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"ayayushsharma/rocket/common"
+	"ayayushsharma/rocket/constants"
+	"ayayushsharma/rocket/containers"
+)
+
+var logsCmd = &cobra.Command{
+	Use:   "logs [container-name]",
+	Short: "Shows logs of a rocket application or the router",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
+		options := containers.LogOptions{
+			Follow: viper.GetBool("follow"),
+			Tail:   viper.GetInt("tail"),
+		}
+
+		since, err := parseSince(viper.GetString("since"))
+		if err != nil {
+			return
+		}
+		options.Since = since
+
+		ctx := cmd.Context()
+		conn, err := containerManager()
+		if err != nil {
+			slog.Debug("Failed to connect to container runtime. Exiting")
+			return
+		}
+
+		appName := common.CompleteAppName(args[0])
+		err = conn.Logs(ctx, appName, options, os.Stdout, os.Stderr)
+		if err != nil {
+			slog.Debug("Failed to read logs", "application", appName, "error", err)
+			return
+		}
+
+		return nil
+	},
+	ValidArgsFunction: logsAppCompletionFn,
+}
+
+func init() {
+	rootCmd.AddCommand(logsCmd)
+	logsCmd.Flags().BoolP("follow", "f", false, "Follow log output")
+	logsCmd.Flags().Int("tail", -1, "Number of lines to show from the end of the logs")
+	logsCmd.Flags().String(
+		"since",
+		"",
+		"Show logs since a duration (e.g. 10m) or RFC3339 timestamp",
+	)
+}
+
+// Parses durations relative to now, or absolute RFC3339 timestamps
+func parseSince(since string) (time.Time, error) {
+	if since == "" {
+		return time.Time{}, nil
+	}
+
+	if duration, err := time.ParseDuration(since); err == nil {
+		return time.Now().Add(-duration), nil
+	}
+
+	timestamp, err := time.Parse(time.RFC3339, since)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid --since value %q", since)
+	}
+	return timestamp, nil
+}
+
+func logsAppCompletionFn(cmd *cobra.Command, args []string, toComplete string) (
+	completion []cobra.Completion,
+	shellDirective cobra.ShellCompDirective,
+) {
+	completion, shellDirective = stopAppCompletionFn(cmd, args, toComplete)
+
+	router := common.ShortenAppName(constants.RouterContainer)
+	if len(toComplete) <= len(router) && router[:len(toComplete)] == toComplete {
+		completion = append(completion, router)
+	}
+
+	return completion, cobra.ShellCompDirectiveNoFileComp
+}
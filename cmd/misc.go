@@ -8,7 +8,6 @@ import (
 	"github.com/spf13/cobra"
 
 	"ayayushsharma/rocket/constants"
-	"ayayushsharma/rocket/containers"
 	"ayayushsharma/rocket/registry"
 )
 
@@ -18,11 +17,12 @@ var miscCmd = &cobra.Command{
 
 	Run: func(cmd *cobra.Command, args []string) {
 		slog.Debug("Misc... " + constants.ApplicationName)
-		conn, err := containers.Manager()
+		ctx := cmd.Context()
+		conn, err := containerManager()
 		if err != nil {
 
 		}
-		networks, _ := conn.ListNetworks()
+		networks, _ := conn.ListNetworks(ctx)
 		for _, val := range networks {
 			fmt.Println(val)
 		}
@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"ayayushsharma/rocket/common"
+	"ayayushsharma/rocket/constants"
+	"ayayushsharma/rocket/containers"
+	"ayayushsharma/rocket/workspace"
+)
+
+// State shown for registered apps whose container is not created yet
+const stateNotCreated = "not created"
+
+type appStatus struct {
+	AppName       string
+	ContainerName string
+	URL           string
+	Egress        string
+	Status        containers.ContainerStatus
+}
+
+var psCmd = &cobra.Command{
+	Use:   "ps",
+	Short: "Lists registered applications with their live state",
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
+		ctx := cmd.Context()
+		conn, err := containerManager()
+		if err != nil {
+			return
+		}
+
+		statuses, err := appStatuses(ctx, conn)
+		if err != nil {
+			return
+		}
+
+		writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(writer, "NAME\tAPP\tURL\tEGRESS\tSTATE\tSTARTED\tRESTARTS")
+		for _, app := range statuses {
+			fmt.Fprintf(
+				writer,
+				"%s\t%s\t%s\t%s\t%s\t%s\t%d\n",
+				common.ShortenAppName(app.ContainerName),
+				app.AppName,
+				app.URL,
+				app.Egress,
+				app.Status.State,
+				startedAgo(app.Status),
+				app.Status.RestartCount,
+			)
+		}
+		return writer.Flush()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(psCmd)
+}
+
+// Joins registered applications with the state of their containers. Router
+// is always listed first
+func appStatuses(ctx context.Context, conn containers.ContainerManager) (
+	statuses []appStatus, err error,
+) {
+	apps, err := workspace.GetApps()
+	if err != nil {
+		return nil, err
+	}
+
+	routerStatus, err := containerStatus(ctx, conn, constants.RouterContainer)
+	if err != nil {
+		return nil, err
+	}
+	statuses = append(statuses, appStatus{
+		AppName:       "router",
+		ContainerName: constants.RouterContainer,
+		URL:           common.AppURL("app.localhost"),
+		Egress:        "-",
+		Status:        routerStatus,
+	})
+
+	appNames := []string{}
+	for appName := range apps {
+		appNames = append(appNames, appName)
+	}
+	slices.Sort(appNames)
+
+	for _, appName := range appNames {
+		appCfg := apps[appName]
+		status, err := containerStatus(ctx, conn, appName)
+		if err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, appStatus{
+			AppName:       appCfg.ApplicationName,
+			ContainerName: appName,
+			URL:           common.AppURL(appCfg.SubDomain),
+			Egress:        appCfg.Egress.String(),
+			Status:        status,
+		})
+	}
+
+	return statuses, nil
+}
+
+// Inspects container while treating missing containers as not created
+func containerStatus(
+	ctx context.Context,
+	conn containers.ContainerManager,
+	containerName string,
+) (status containers.ContainerStatus, err error) {
+	status, err = conn.InspectContainer(ctx, containerName)
+	if errors.Is(err, containers.ContainerDoesntExistErr) {
+		return containers.ContainerStatus{
+			Name:  containerName,
+			State: stateNotCreated,
+		}, nil
+	}
+	return status, err
+}
+
+func startedAgo(status containers.ContainerStatus) string {
+	if !status.Running() || status.StartedAt.IsZero() {
+		return "-"
+	}
+	return time.Since(status.StartedAt).Round(time.Second).String() + " ago"
+}
+
+func formatPorts(ports map[int]int) string {
+	if len(ports) == 0 {
+		return "-"
+	}
+
+	hostPorts := []int{}
+	for hostPort := range ports {
+		hostPorts = append(hostPorts, hostPort)
+	}
+	slices.Sort(hostPorts)
+
+	mappings := []string{}
+	for _, hostPort := range hostPorts {
+		mappings = append(mappings, fmt.Sprintf("%d->%d", hostPort, ports[hostPort]))
+	}
+	return strings.Join(mappings, ", ")
+}
@@ -0,0 +1,193 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+	"golang.org/x/term"
+
+	"ayayushsharma/rocket/containers"
+	"ayayushsharma/rocket/workspace"
+)
+
+// Width of the progress bar drawn on terminals
+const progressBarWidth = 30
+
+// Minimum time between two redraws of the progress line
+const redrawInterval = 100 * time.Millisecond
+
+// Layer statuses after which the layer is fully downloaded
+var layerDoneStatuses = []string{
+	"Download complete",
+	"Pull complete",
+	"Already exists",
+}
+
+type layerProgress struct {
+	status  string
+	current int64
+	total   int64
+	done    bool
+}
+
+// Renders progress of an image pull. Terminals get a single line redrawn in
+// place, other outputs get a line for every status change of a layer
+type pullRenderer struct {
+	image    string
+	out      io.Writer
+	tty      bool
+	started  time.Time
+	lastDraw time.Time
+	layers   map[string]*layerProgress
+	order    []string
+}
+
+// Pulls the image showing its progress on stderr, unless `quiet` is set.
+// Pulled images are recorded in the workspace
+func pullImage(
+	ctx context.Context,
+	conn containers.ContainerManager,
+	imageName string,
+) error {
+	options := containers.PullOptions{}
+	if !viper.GetBool("quiet") {
+		renderer := newPullRenderer(imageName, os.Stderr)
+		options.Progress = renderer.update
+	}
+	if err := conn.PullImage(ctx, imageName, options); err != nil {
+		return err
+	}
+
+	// images are tracked so that gc knows which ones rocket brought in
+	if err := workspace.RecordPulledImage(imageName); err != nil {
+		slog.Debug("Failed to record pulled image", "image", imageName, "error", err)
+	}
+	return nil
+}
+
+func newPullRenderer(imageName string, out *os.File) *pullRenderer {
+	return &pullRenderer{
+		image:   imageName,
+		out:     out,
+		tty:     term.IsTerminal(int(out.Fd())),
+		started: time.Now(),
+		layers:  map[string]*layerProgress{},
+	}
+}
+
+func (r *pullRenderer) update(progress containers.PullProgress) {
+	if progress.Done {
+		r.finish(progress.Total)
+		return
+	}
+
+	if progress.Layer == "" {
+		if !r.tty && progress.Status != "" {
+			fmt.Fprintf(r.out, "%s: %s\n", r.image, progress.Status)
+		}
+		return
+	}
+
+	layer, ok := r.layers[progress.Layer]
+	if !ok {
+		layer = &layerProgress{}
+		r.layers[progress.Layer] = layer
+		r.order = append(r.order, progress.Layer)
+	}
+
+	statusChanged := layer.status != progress.Status
+	layer.status = progress.Status
+	if progress.Total > 0 {
+		layer.total = progress.Total
+	}
+	// extraction reports progress too, only downloads count towards the bar
+	if progress.Status == "Downloading" {
+		layer.current = progress.Current
+	}
+	if slices.Contains(layerDoneStatuses, progress.Status) {
+		layer.done = true
+		layer.current = layer.total
+	}
+
+	if !r.tty {
+		if statusChanged {
+			fmt.Fprintf(r.out, "%s: %s\n", progress.Layer, progress.Status)
+		}
+		return
+	}
+
+	if time.Since(r.lastDraw) >= redrawInterval {
+		r.draw()
+	}
+}
+
+func (r *pullRenderer) draw() {
+	r.lastDraw = time.Now()
+
+	var current, total int64
+	done := 0
+	for _, layerID := range r.order {
+		layer := r.layers[layerID]
+		current += layer.current
+		total += layer.total
+		if layer.done {
+			done++
+		}
+	}
+
+	line := fmt.Sprintf("Pulling %s ", r.image)
+	if total > 0 {
+		filled := int(float64(progressBarWidth) * float64(current) / float64(total))
+		filled = min(filled, progressBarWidth)
+		line += fmt.Sprintf(
+			"[%s%s] %s/%s",
+			strings.Repeat("=", filled),
+			strings.Repeat(" ", progressBarWidth-filled),
+			humanSize(current),
+			humanSize(total),
+		)
+	} else {
+		line += fmt.Sprintf("%d/%d layers", done, len(r.order))
+	}
+	line += " " + r.elapsed()
+
+	fmt.Fprintf(r.out, "\r\033[K%s", line)
+}
+
+func (r *pullRenderer) finish(size int64) {
+	if r.tty {
+		fmt.Fprint(r.out, "\r\033[K")
+	}
+
+	if size > 0 {
+		fmt.Fprintf(r.out, "Pulled %s (%s) in %s\n", r.image, humanSize(size), r.elapsed())
+		return
+	}
+	fmt.Fprintf(r.out, "Pulled %s in %s\n", r.image, r.elapsed())
+}
+
+func (r *pullRenderer) elapsed() string {
+	return time.Since(r.started).Round(time.Second).String()
+}
+
+// Formats bytes with decimal units e.g. 45.2MB
+func humanSize(bytes int64) string {
+	const unit = 1000
+	if bytes < unit {
+		return fmt.Sprintf("%dB", bytes)
+	}
+
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%cB", float64(bytes)/float64(div), "kMGTPE"[exp])
+}
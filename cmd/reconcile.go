@@ -0,0 +1,300 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"ayayushsharma/rocket/common"
+	"ayayushsharma/rocket/constants"
+	"ayayushsharma/rocket/containers"
+	"ayayushsharma/rocket/workspace"
+)
+
+// Differences between the workspace and containers found in the runtime
+type drift struct {
+	// managed containers whose app is not registered in the workspace
+	orphans []string
+	// pods of orphaned stack containers
+	orphanPods []string
+	// registered apps without a container
+	ghosts []string
+	// containers created from an older config of their app
+	outdated []string
+	// registered apps with containers created before rocket labelled them
+	unlabelled []string
+}
+
+var reconcileCmd = &cobra.Command{
+	Use:   "reconcile",
+	Short: "Finds containers and registered apps that are out of sync",
+	Long: `Compares containers created by rocket against the workspace.
+
+Orphans are containers of apps that are no longer registered, ghosts are
+registered apps without a container and outdated containers were created
+from an older config of their app. Containers of registered apps created by
+older versions of rocket carry no labels and are found by name. With --apply
+orphans are removed along with their pods, ghosts are created and apps
+without labels are created again.`,
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
+		ctx := cmd.Context()
+		conn, err := containerManager()
+		if err != nil {
+			return
+		}
+
+		apps, err := workspace.GetApps()
+		if err != nil {
+			return
+		}
+
+		found, err := findDrift(ctx, conn, apps)
+		if err != nil {
+			return
+		}
+		found.print()
+
+		if !viper.GetBool("apply") {
+			return nil
+		}
+		return found.apply(ctx, conn, apps)
+	},
+}
+
+// Containers rocket runs for itself rather than for a registered app
+var systemContainers = []string{
+	constants.RouterContainer,
+	constants.EgressProxyContainer,
+}
+
+func init() {
+	rootCmd.AddCommand(reconcileCmd)
+	reconcileCmd.Flags().Bool(
+		"apply", false, "Remove orphaned containers and create missing or unlabelled ones",
+	)
+}
+
+// Workspace entries having at least one running container. Containers are
+// matched to apps by name, so that containers created before rocket
+// labelled them are found too
+func runningAppNames(
+	ctx context.Context,
+	conn containers.ContainerManager,
+	apps map[string]containers.Config,
+) (running map[string]bool, err error) {
+	runningNames, err := conn.ListContainers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	running = map[string]bool{}
+	for appName, appCfg := range apps {
+		for _, containerName := range appContainerNames(appCfg) {
+			if slices.Contains(runningNames, containerName) {
+				running[appName] = true
+				break
+			}
+		}
+	}
+	return running, nil
+}
+
+// Names of the containers of the app, stack members included
+func appContainerNames(appCfg containers.Config) (names []string) {
+	if !appCfg.IsStack() {
+		return []string{appCfg.ContainerName}
+	}
+	for _, member := range appCfg.StackContainers() {
+		names = append(names, member.ContainerName)
+	}
+	return names
+}
+
+func findDrift(
+	ctx context.Context,
+	conn containers.ContainerManager,
+	apps map[string]containers.Config,
+) (found drift, err error) {
+	managed, err := conn.ListManagedContainers(ctx)
+	if err != nil {
+		return found, err
+	}
+
+	// expected config hash of every container the workspace describes
+	expected := map[string]string{}
+	for _, appCfg := range apps {
+		if !appCfg.IsStack() {
+			expected[appCfg.ContainerName] = appCfg.Hash()
+			continue
+		}
+		for _, member := range appCfg.StackContainers() {
+			expected[member.ContainerName] = member.Hash()
+		}
+	}
+
+	labelled := map[string]bool{}
+	orphanApps := map[string]bool{}
+	for _, container := range managed {
+		labelled[container.Name] = true
+		if slices.Contains(systemContainers, container.App()) {
+			continue
+		}
+		if _, registered := apps[container.App()]; !registered {
+			found.orphans = append(found.orphans, container.Name)
+			orphanApps[container.App()] = true
+			continue
+		}
+		hash, ok := expected[container.Name]
+		if ok && hash != container.ConfigHash() {
+			found.outdated = append(found.outdated, container.Name)
+		}
+	}
+
+	// pods of orphaned stacks are left once their last container is removed
+	for appName := range orphanApps {
+		podName := containers.Config{ContainerName: appName}.PodName()
+		exists, err := conn.PodExists(ctx, podName)
+		if errors.Is(err, containers.PodsUnsupportedErr) {
+			break
+		}
+		if err != nil {
+			return found, err
+		}
+		if exists {
+			found.orphanPods = append(found.orphanPods, podName)
+		}
+	}
+
+	for appName, appCfg := range apps {
+		exists, err := appExists(ctx, conn, appCfg)
+		if err != nil {
+			return found, err
+		}
+		if !exists {
+			found.ghosts = append(found.ghosts, appName)
+			continue
+		}
+
+		for _, containerName := range appContainerNames(appCfg) {
+			if labelled[containerName] {
+				continue
+			}
+			exists, err := conn.ContainerExists(ctx, containerName)
+			if err != nil {
+				return found, err
+			}
+			if exists {
+				found.unlabelled = append(found.unlabelled, appName)
+				break
+			}
+		}
+	}
+
+	slices.Sort(found.orphans)
+	slices.Sort(found.orphanPods)
+	slices.Sort(found.ghosts)
+	slices.Sort(found.outdated)
+	slices.Sort(found.unlabelled)
+	return found, nil
+}
+
+func (d drift) print() {
+	if len(d.orphans)+len(d.orphanPods)+len(d.ghosts)+len(d.outdated)+len(d.unlabelled) == 0 {
+		fmt.Println("Workspace and containers are in sync")
+		return
+	}
+
+	sections := []struct {
+		title string
+		names []string
+	}{
+		{"Orphaned containers", d.orphans},
+		{"Orphaned pods", d.orphanPods},
+		{"Registered apps without container", d.ghosts},
+		{"Created from an older config", d.outdated},
+		{"Created without rocket labels", d.unlabelled},
+	}
+
+	for _, section := range sections {
+		if len(section.names) == 0 {
+			continue
+		}
+		fmt.Printf("%s:\n", section.title)
+		for _, name := range section.names {
+			fmt.Printf("  %s\n", common.ShortenAppName(name))
+		}
+	}
+}
+
+func (d drift) apply(
+	ctx context.Context,
+	conn containers.ContainerManager,
+	apps map[string]containers.Config,
+) (err error) {
+	for _, name := range d.orphans {
+		slog.Debug("Removing orphaned container", "name", name)
+		if err = conn.RemoveContainer(ctx, name, true); err != nil {
+			return fmt.Errorf("remove orphaned container %q failed: %w", name, err)
+		}
+	}
+
+	for _, podName := range d.orphanPods {
+		slog.Debug("Removing orphaned pod", "name", podName)
+		if err = conn.RemovePod(ctx, podName, true); err != nil {
+			return fmt.Errorf("remove orphaned pod %q failed: %w", podName, err)
+		}
+	}
+
+	for _, appName := range d.ghosts {
+		slog.Debug("Creating missing container", "app", appName)
+		if err = createApp(ctx, conn, apps[appName]); err != nil {
+			return fmt.Errorf("create app %q failed: %w", appName, err)
+		}
+	}
+
+	for _, appName := range d.unlabelled {
+		slog.Debug("Creating unlabelled app again", "app", appName)
+		if err = recreateApp(ctx, conn, apps[appName]); err != nil {
+			return fmt.Errorf("recreate app %q failed: %w", appName, err)
+		}
+	}
+
+	return nil
+}
+
+// Removes the containers of the app and creates them again, starting the
+// app when it was running. Volumes of the app are kept
+func recreateApp(
+	ctx context.Context,
+	conn containers.ContainerManager,
+	appCfg containers.Config,
+) (err error) {
+	running, err := runningAppNames(ctx, conn, map[string]containers.Config{
+		appCfg.ContainerName: appCfg,
+	})
+	if err != nil {
+		return err
+	}
+
+	if appCfg.IsStack() {
+		err = containers.RemoveStack(ctx, conn, appCfg, true)
+	} else {
+		err = conn.RemoveContainer(ctx, appCfg.ContainerName, true)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err = createApp(ctx, conn, appCfg); err != nil {
+		return err
+	}
+	if !running[appCfg.ContainerName] {
+		return nil
+	}
+	return startApp(ctx, conn, appCfg)
+}
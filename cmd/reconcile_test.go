@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"slices"
+	"testing"
+
+	"ayayushsharma/rocket/containers"
+	"ayayushsharma/rocket/workspace"
+)
+
+func TestReconcileRemovesOrphanedStack(t *testing.T) {
+	useTempWorkspace(t)
+	conn := newTestManager(t)
+	appCfg := registerTestApp(t, "rocket-reconcile-stack", containers.Config{
+		ImageURL:      "docker.io/library/redis",
+		ImageVersion:  "alpine",
+		ContainerName: "redis",
+	})
+	if err := launchApp(t.Context(), conn, appCfg.ContainerName); err != nil {
+		t.Fatalf("launchApp: %v", err)
+	}
+	if err := workspace.Unregister(appCfg.ContainerName); err != nil {
+		t.Fatal(err)
+	}
+
+	apps, err := workspace.GetApps()
+	if err != nil {
+		t.Fatal(err)
+	}
+	found, err := findDrift(t.Context(), conn, apps)
+	if err != nil {
+		t.Fatalf("findDrift: %v", err)
+	}
+	if !slices.Equal(found.orphanPods, []string{appCfg.PodName()}) {
+		t.Errorf("orphanPods = %v, want [%s]", found.orphanPods, appCfg.PodName())
+	}
+	if err = found.apply(t.Context(), conn, apps); err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+
+	exists, err := conn.PodExists(t.Context(), appCfg.PodName())
+	if err != nil || exists {
+		t.Errorf("PodExists = %v, %v, want false", exists, err)
+	}
+	for _, containerName := range appContainerNames(appCfg) {
+		exists, err = conn.ContainerExists(t.Context(), containerName)
+		if err != nil || exists {
+			t.Errorf("ContainerExists(%q) = %v, %v, want false", containerName, exists, err)
+		}
+	}
+}
+
+func TestRunningAppNames(t *testing.T) {
+	useTempWorkspace(t)
+	conn := newTestManager(t)
+	registerTestApp(t, "rocket-running")
+	registerTestApp(t, "rocket-stopped")
+	if err := launchApp(t.Context(), conn, "rocket-running"); err != nil {
+		t.Fatalf("launchApp: %v", err)
+	}
+
+	apps, err := workspace.GetApps()
+	if err != nil {
+		t.Fatal(err)
+	}
+	running, err := runningAppNames(t.Context(), conn, apps)
+	if err != nil {
+		t.Fatalf("runningAppNames: %v", err)
+	}
+	if !running["rocket-running"] || running["rocket-stopped"] {
+		t.Errorf("runningAppNames = %v, want only rocket-running", running)
+	}
+}
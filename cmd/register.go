@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log/slog"
@@ -9,6 +10,7 @@ import (
 	"github.com/spf13/viper"
 
 	"ayayushsharma/rocket/constants"
+	"ayayushsharma/rocket/containers"
 	"ayayushsharma/rocket/registry"
 	"ayayushsharma/rocket/workspace"
 )
@@ -43,7 +45,11 @@ var registerCmd = &cobra.Command{
 		networkName := viper.GetString("routes.network")
 		slog.Debug("Network found", "name", networkName)
 		appToRegister.NetworkName = networkName
-		err = workspace.Register(appToRegister)
+		conn, err := containerManager()
+		if err != nil {
+			return
+		}
+		err = registerApp(cmd.Context(), conn, appToRegister)
 
 		if err != nil {
 			var alreadyRegistered *workspace.AppAlreadyRegisteredErr
@@ -55,17 +61,42 @@ var registerCmd = &cobra.Command{
 				fmt.Println("Edit it's conf and sync to get desired app state")
 				return nil
 			}
-			slog.Debug("Failed to register app to workspace", "error", err)
+			slog.Debug("Failed to register app", "error", err)
 			return
 		}
 
 		fmt.Println("Application Successfully registered as:")
 		fmt.Println(appToRegister.ContainerName)
+		syncServiceUnits(cmd.Context())
 
 		return nil
 	},
 }
 
+// Registers the app to the workspace and pins its images, so that the app runs
+// the image it was registered with even when its tag moves before the first
+// launch. The registration is rolled back if its images can't be pinned
+func registerApp(
+	ctx context.Context,
+	conn containers.ContainerManager,
+	appCfg containers.Config,
+) (err error) {
+	if err = workspace.Register(appCfg); err != nil {
+		return err
+	}
+
+	if _, err = pinApp(ctx, conn, appCfg, false); err != nil {
+		slog.Debug("Failed to pin app images", "error", err)
+		if unregisterErr := workspace.Unregister(appCfg.ContainerName); unregisterErr != nil {
+			slog.Error("Failed to roll back registration", "app", appCfg.ContainerName, "error", unregisterErr)
+		}
+		return err
+	}
+
+	return nil
+}
+
 func init() {
 	rootCmd.AddCommand(registerCmd)
+	registerCmd.Flags().BoolP("quiet", "q", false, "Do not show image pull progress")
 }
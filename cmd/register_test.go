@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"errors"
+	"testing"
+
+	"ayayushsharma/rocket/workspace"
+)
+
+func TestRegisterAppPinsImages(t *testing.T) {
+	useTempWorkspace(t)
+	conn := newTestManager(t)
+	appCfg := testApp("rocket-register")
+
+	if err := registerApp(t.Context(), conn, appCfg); err != nil {
+		t.Fatalf("registerApp: %v", err)
+	}
+
+	if _, err := workspace.GetAppCfg(appCfg.ContainerName); err != nil {
+		t.Errorf("app not registered: %v", err)
+	}
+	pins, err := workspace.GetPins()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := pins[appCfg.Image()]; !ok {
+		t.Errorf("pins = %v, want %q pinned", pins, appCfg.Image())
+	}
+}
+
+func TestRegisterAppRollsBackUnpinnedApp(t *testing.T) {
+	useTempWorkspace(t)
+	conn := newTestManager(t)
+	rejectAllImages(t)
+	appCfg := testApp("rocket-register-rejected")
+
+	if err := registerApp(t.Context(), conn, appCfg); err == nil {
+		t.Fatal("registered an app whose images failed verification")
+	}
+
+	apps, err := workspace.GetApps()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := apps[appCfg.ContainerName]; ok {
+		t.Error("app left registered without pinned images")
+	}
+	pins, err := workspace.GetPins()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pins) != 0 {
+		t.Errorf("pins = %v, want none recorded", pins)
+	}
+}
+
+func TestRegisterAppAlreadyRegistered(t *testing.T) {
+	useTempWorkspace(t)
+	conn := newTestManager(t)
+	appCfg := registerTestApp(t, "rocket-register-twice")
+	rejectAllImages(t)
+
+	err := registerApp(t.Context(), conn, appCfg)
+	var alreadyRegistered *workspace.AppAlreadyRegisteredErr
+	if !errors.As(err, &alreadyRegistered) {
+		t.Fatalf("error = %v, want AppAlreadyRegisteredErr", err)
+	}
+
+	// the existing registration is left alone
+	if _, err := workspace.GetAppCfg(appCfg.ContainerName); err != nil {
+		t.Errorf("existing app unregistered: %v", err)
+	}
+}
@@ -7,7 +7,6 @@ import (
 	"github.com/spf13/cobra"
 
 	"ayayushsharma/rocket/constants"
-	"ayayushsharma/rocket/containers"
 )
 
 var registryCmd = &cobra.Command{
@@ -16,7 +15,7 @@ var registryCmd = &cobra.Command{
 
 	Run: func(cmd *cobra.Command, args []string) {
 		slog.Debug("Adding to registry... " + constants.ApplicationName)
-		_, err := containers.Manager()
+		_, err := containerManager()
 		if err != nil {
 
 		}
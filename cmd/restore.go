@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"ayayushsharma/rocket/backup"
+	"ayayushsharma/rocket/common"
+	"ayayushsharma/rocket/containers"
+	"ayayushsharma/rocket/workspace"
+)
+
+var restoreCmd = &cobra.Command{
+	Use:   "restore <archive>",
+	Short: "Registers the app of a backup again and restores its data",
+	Long: `Registers the app with the config in the archive, creates its containers
+and writes the backed up volumes and bind mounts back. Files missing from the
+archive are kept.
+
+Apps that are still registered are only restored with --force, which removes
+their containers and volumes first so that volumes hold exactly what was
+backed up. Bind mounts are written over, never emptied.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
+		ctx := cmd.Context()
+		conn, err := containerManager()
+		if err != nil {
+			return
+		}
+		return restoreApp(ctx, conn, args[0])
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(restoreCmd)
+	restoreCmd.Flags().Bool("force", false, "Replace the app when it is still registered")
+	restoreCmd.Flags().Bool("launch", false, "Launch the app once restored")
+	restoreCmd.Flags().BoolP("quiet", "q", false, "Do not show image pull progress")
+}
+
+func restoreApp(
+	ctx context.Context,
+	conn containers.ContainerManager,
+	archive string,
+) (err error) {
+	reader, err := backup.Open(archive)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	appCfg := reader.Config
+	appName := appCfg.ContainerName
+	appCfg.NetworkName = viper.GetString("routes.network")
+
+	_, err = workspace.GetAppCfg(appName)
+	switch {
+	case err == nil:
+		if !viper.GetBool("force") {
+			return fmt.Errorf(
+				"%s is already registered, restore over it with --force",
+				common.ShortenAppName(appName),
+			)
+		}
+		if err = unregisterApplication(ctx, conn, appName); err != nil {
+			return err
+		}
+		for _, volumeName := range reader.Manifest.Volumes {
+			err = conn.RemoveVolume(ctx, volumeName, true)
+			if err != nil {
+				slog.Debug("Volume not removed", "volume", volumeName, "error", err)
+			}
+		}
+	case !errors.Is(err, workspace.AppNotRegisteredErr):
+		return err
+	}
+
+	// binds fail to mount when their source is missing
+	for _, dir := range reader.Binds() {
+		if err = os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+
+	if err = workspace.Register(appCfg); err != nil {
+		return err
+	}
+	syncServiceUnits(ctx)
+
+	// volumes are created along with the containers
+	exists, err := appExists(ctx, conn, appCfg)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		if err = createApp(ctx, conn, appCfg); err != nil {
+			return fmt.Errorf("create app: %w", err)
+		}
+	}
+
+	err = reader.Restore(func(volumeName string, contents io.Reader) error {
+		return conn.ImportVolume(ctx, volumeName, contents)
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf(
+		"Restored %s from backup of %s\n",
+		common.ShortenAppName(appName),
+		reader.Manifest.Created.Local().Format(time.DateTime),
+	)
+
+	if !viper.GetBool("launch") {
+		return nil
+	}
+	return startApp(ctx, conn, appCfg)
+}
@@ -2,7 +2,6 @@ package cmd
 
 import (
 	"ayayushsharma/rocket/constants"
-	"ayayushsharma/rocket/containers"
 	"log/slog"
 
 	"github.com/spf13/cobra"
@@ -14,18 +13,19 @@ var resumeCmd = &cobra.Command{
 	Short: "Resume rockets from where they left off",
 	RunE: func(cmd *cobra.Command, args []string) (err error) {
 		slog.Debug("Resuming... " + constants.ApplicationName)
-		conn, err := containers.Manager()
+		ctx := cmd.Context()
+		conn, err := containerManager()
 		if err != nil {
 			slog.Debug("Could not connect to podman", "error", err)
 			return
 		}
-		err = startRouter(conn)
+		err = startRouter(ctx, conn)
 		if err != nil {
 			slog.Debug("Failed to start application router", "error", err)
 			return
 		}
 
-		err = launchAll(conn)
+		err = launchAll(ctx, conn)
 		if err != nil {
 			slog.Debug("Failed to start application router", "error", err)
 			return
@@ -1,15 +1,20 @@
 package cmd
 
 import (
+	"context"
 	"errors"
 	"log/slog"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 
 	"ayayushsharma/rocket/constants"
+	"ayayushsharma/rocket/containers"
 	"ayayushsharma/rocket/resources"
 )
 
@@ -41,8 +46,16 @@ var rootCmd = &cobra.Command{
 }
 
 func Execute() {
-	err := rootCmd.Execute()
+	// cancelled on Ctrl-C or SIGTERM, aborting container runtime calls in
+	// flight instead of leaving them running in the background
+	ctx, stop := signal.NotifyContext(
+		context.Background(), os.Interrupt, syscall.SIGTERM,
+	)
+	defer stop()
+
+	err := rootCmd.ExecuteContext(ctx)
 	if err != nil {
+		stop()
 		os.Exit(1)
 	}
 }
@@ -54,6 +67,36 @@ func init() {
 		"",
 		"config file (default is $XDG_CONFIG_HOME/rocket/config.yaml)",
 	)
+	rootCmd.PersistentFlags().String(
+		"runtime",
+		containers.RuntimePodman,
+		"container runtime to use (podman, docker)",
+	)
+	rootCmd.PersistentFlags().String(
+		"connection",
+		"",
+		"podman system connection to use instead of the default one",
+	)
+	rootCmd.PersistentFlags().Duration(
+		"pull-timeout",
+		10*time.Minute,
+		"maximum time an image pull may take, 0 for no limit",
+	)
+	rootCmd.PersistentFlags().Duration(
+		"create-timeout",
+		2*time.Minute,
+		"maximum time creating a container may take, 0 for no limit",
+	)
+	rootCmd.PersistentFlags().Duration(
+		"stop-timeout",
+		0,
+		"time apps get to exit when stopped before they are killed, 0 uses the stop timeout of each app",
+	)
+	rootCmd.PersistentFlags().Bool(
+		"insecure-skip-verify",
+		false,
+		"create apps without checking image signatures against the trust policy",
+	)
 }
 
 func initializeConfig(cmd *cobra.Command) error {
@@ -94,6 +137,20 @@ func initializeConfig(cmd *cobra.Command) error {
 	return nil
 }
 
+// Connects to the container runtime selected by `runtime` and `connection`
+// config keys or flags, with timeouts from the `*-timeout` keys
+func containerManager() (containers.ContainerManager, error) {
+	return containers.Manager(containers.ConnectOptions{
+		Runtime:    viper.GetString("runtime"),
+		Connection: viper.GetString("connection"),
+		Timeouts: containers.Timeouts{
+			Pull:      viper.GetDuration("pull-timeout"),
+			Create:    viper.GetDuration("create-timeout"),
+			StopGrace: viper.GetDuration("stop-timeout"),
+		},
+	})
+}
+
 func confimAppDataExists() error {
 	if !resources.CheckAll() {
 		slog.Debug("App Data files not already present")
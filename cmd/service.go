@@ -0,0 +1,162 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"ayayushsharma/rocket/common"
+	"ayayushsharma/rocket/service"
+	"ayayushsharma/rocket/workspace"
+)
+
+var serviceCmd = &cobra.Command{
+	Use:   "service",
+	Short: "Manages systemd units starting rocket at boot",
+	Long: `Manages systemd user units starting the router and registered apps at boot.
+
+Every app gets a unit running "rocket launch", ordered after the unit of the
+router. Apps with ManualStart set in the workspace are left out. Once
+installed, units follow register, unregister and sync.`,
+}
+
+var serviceInstallCmd = &cobra.Command{
+	Use:   "install",
+	Short: "Generates and enables units for the router and apps",
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
+		ctx := cmd.Context()
+		apps, err := workspace.GetApps()
+		if err != nil {
+			return
+		}
+
+		options, err := serviceOptions()
+		if err != nil {
+			return
+		}
+
+		if err = service.Install(ctx, apps, options); err != nil {
+			slog.Debug("Failed to install units", "error", err)
+			return
+		}
+
+		fmt.Println("Rocket will start at boot")
+		if !service.Lingering() {
+			fmt.Println("Run `loginctl enable-linger` to start it before logging in")
+		}
+		return nil
+	},
+}
+
+var serviceUninstallCmd = &cobra.Command{
+	Use:   "uninstall",
+	Short: "Disables and removes generated units, apps keep running",
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
+		if err = service.Uninstall(cmd.Context()); err != nil {
+			slog.Debug("Failed to uninstall units", "error", err)
+			return
+		}
+		fmt.Println("Rocket will no longer start at boot")
+		return nil
+	},
+}
+
+var serviceStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Lists generated units with their state",
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
+		if !service.Installed() {
+			fmt.Println("Units not installed, run `rocket service install`")
+			return nil
+		}
+
+		statuses, err := service.Status(cmd.Context())
+		if err != nil {
+			return
+		}
+
+		writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(writer, "UNIT\tNAME\tENABLED\tACTIVE")
+		for _, status := range statuses {
+			fmt.Fprintf(
+				writer,
+				"%s\t%s\t%s\t%s\n",
+				status.Unit,
+				common.ShortenAppName(status.AppName),
+				status.Enabled,
+				status.Active,
+			)
+		}
+		if err = writer.Flush(); err != nil {
+			return
+		}
+
+		if !service.Lingering() {
+			fmt.Println("\nLingering is off, units start on login instead of boot")
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(serviceCmd)
+	serviceCmd.AddCommand(serviceInstallCmd)
+	serviceCmd.AddCommand(serviceUninstallCmd)
+	serviceCmd.AddCommand(serviceStatusCmd)
+}
+
+// Units invoke the running binary with the config and runtime in use now
+func serviceOptions() (options service.Options, err error) {
+	executable, err := os.Executable()
+	if err != nil {
+		return options, err
+	}
+	if executable, err = filepath.EvalSymlinks(executable); err != nil {
+		return options, err
+	}
+
+	options = service.Options{
+		Executable: executable,
+		Runtime:    viper.GetString("runtime"),
+	}
+	if cfgFile != "" {
+		configPath, err := filepath.Abs(cfgFile)
+		if err != nil {
+			return options, err
+		}
+		options.Args = append(options.Args, "--config", configPath)
+	}
+	options.Args = append(options.Args, "--runtime", options.Runtime)
+	if connection := viper.GetString("connection"); connection != "" {
+		options.Args = append(options.Args, "--connection", connection)
+	}
+	return options, nil
+}
+
+// Regenerates installed units after the workspace changed. Failures are
+// reported but do not undo the change
+func syncServiceUnits(ctx context.Context) {
+	if !service.Installed() {
+		return
+	}
+
+	apps, err := workspace.GetApps()
+	if err != nil {
+		slog.Debug("Failed to read apps for units", "error", err)
+		return
+	}
+	options, err := serviceOptions()
+	if err == nil {
+		err = service.Sync(ctx, apps, options)
+	}
+	if err != nil {
+		slog.Debug("Failed to sync units", "error", err)
+		fmt.Fprintf(os.Stderr, "Failed to update boot units: %v\n", err)
+	}
+}
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"context"
 	"log/slog"
 
 	"github.com/spf13/cobra"
@@ -10,18 +11,22 @@ import (
 	"ayayushsharma/rocket/containers"
 )
 
+// Image the router container runs
+const routerImage = "openresty/openresty:alpine"
+
 var startRouterCmd = &cobra.Command{
 	Use:   "start-router",
 	Short: "Starts Rocket routers",
 	RunE: func(cmd *cobra.Command, args []string) (err error) {
 		slog.Debug("Starting rocket router... ")
 		var conn containers.ContainerManager
-		conn, err = containers.Manager()
+		ctx := cmd.Context()
+		conn, err = containerManager()
 		if err != nil {
 			slog.Debug("Failed to connect to podman. Exiting")
 			return
 		}
-		startRouter(conn)
+		startRouter(ctx, conn)
 
 		return nil
 	},
@@ -29,17 +34,21 @@ var startRouterCmd = &cobra.Command{
 
 func init() {
 	rootCmd.AddCommand(startRouterCmd)
+	startRouterCmd.Flags().BoolP("quiet", "q", false, "Do not show image pull progress")
 }
 
-func startRouter(conn containers.ContainerManager) (err error) {
-	imageURL := "openresty/openresty:alpine"
-	imageExists, err := conn.ImageExists(imageURL)
+func startRouter(
+	ctx context.Context,
+	conn containers.ContainerManager,
+) (err error) {
+	imageURL := routerImage
+	imageExists, err := conn.ImageExists(ctx, imageURL)
 	if err != nil {
 		return
 	}
 
 	if !imageExists {
-		if err = conn.PullImage(imageURL); err != nil {
+		if err = pullImage(ctx, conn, imageURL); err != nil {
 			return err
 		}
 		slog.Debug("Pulled router image")
@@ -48,21 +57,23 @@ func startRouter(conn containers.ContainerManager) (err error) {
 	networkName := viper.GetString("routes.network")
 	slog.Debug("Network found in config", "name", networkName)
 
-	networkExists, err := conn.NetworkExists(networkName)
-	if err != nil {
-		return
-	}
-
-	if !networkExists {
-		if err = conn.CreateNetwork(networkName); err != nil {
-			return err
-		}
-		slog.Debug("Created Network", "name", networkName)
+	if err = ensureNetwork(ctx, conn, networkName, true); err != nil {
+		return err
 	}
 
-	mountDirs := map[string]string{
-		constants.NginxConfPath: "/usr/local/openresty/nginx/conf/nginx.conf",
-		constants.HomePageDir:   "/usr/share/nginx/html",
+	mountDirs := containers.MountList{
+		{
+			Type:        containers.MountBind,
+			Source:      constants.NginxConfPath,
+			Destination: "/usr/local/openresty/nginx/conf/nginx.conf",
+			ReadOnly:    true,
+		},
+		{
+			Type:        containers.MountBind,
+			Source:      constants.HomePageDir,
+			Destination: "/usr/share/nginx/html",
+			ReadOnly:    true,
+		},
 	}
 
 	bindPorts := map[int]int{
@@ -79,14 +90,14 @@ func startRouter(conn containers.ContainerManager) (err error) {
 		BindPorts:       bindPorts,
 	}
 
-	err = conn.CreateContainer(routerConfig)
+	err = conn.CreateContainer(ctx, routerConfig)
 	if err != nil {
 		slog.Debug("Could not create router container", "error", err)
 		return
 	}
 	slog.Debug("Created router successfully")
 
-	err = conn.StartService(constants.RouterContainer)
+	err = conn.StartService(ctx, constants.RouterContainer)
 	if err != nil {
 		slog.Debug("Could not start router container", "error", err)
 		return
@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"testing"
+
+	"ayayushsharma/rocket/constants"
+	"ayayushsharma/rocket/containers"
+)
+
+func TestStartRouter(t *testing.T) {
+	useTempWorkspace(t)
+	conn := containers.NewMemoryManager()
+
+	if err := startRouter(t.Context(), conn); err != nil {
+		t.Fatalf("startRouter: %v", err)
+	}
+
+	exists, err := conn.NetworkExists(t.Context(), testNetwork)
+	if err != nil || !exists {
+		t.Errorf("NetworkExists(%q) = %v, %v, want true", testNetwork, exists, err)
+	}
+	state := conn.ContainerState(constants.RouterContainer)
+	if state != containers.MemoryStateRunning {
+		t.Errorf("router state = %q, want %q", state, containers.MemoryStateRunning)
+	}
+
+	// starting an already running router is not an error
+	if err := startRouter(t.Context(), conn); err != nil {
+		t.Fatalf("second startRouter: %v", err)
+	}
+}
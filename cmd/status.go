@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"ayayushsharma/rocket/common"
+	"ayayushsharma/rocket/workspace"
+)
+
+var statusCmd = &cobra.Command{
+	Use:   "status [container-name]",
+	Short: "Shows detailed state of a registered application",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
+		ctx := cmd.Context()
+		conn, err := containerManager()
+		if err != nil {
+			return
+		}
+
+		appName := common.CompleteAppName(args[0])
+		appCfg, err := workspace.GetAppCfg(appName)
+		if err != nil {
+			return
+		}
+
+		status, err := containerStatus(ctx, conn, appName)
+		if err != nil {
+			return
+		}
+
+		startedAt := "-"
+		if !status.StartedAt.IsZero() {
+			startedAt = status.StartedAt.Format(time.RFC1123)
+		}
+
+		writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(writer, "Name:\t%s\n", common.ShortenAppName(appName))
+		fmt.Fprintf(writer, "Application:\t%s\n", appCfg.ApplicationName)
+		fmt.Fprintf(writer, "URL:\t%s\n", common.AppURL(appCfg.SubDomain))
+		fmt.Fprintf(writer, "Image:\t%s\n", appCfg.Image())
+		fmt.Fprintf(writer, "Image digest:\t%s\n", status.ImageDigest)
+		fmt.Fprintf(writer, "State:\t%s\n", status.State)
+		fmt.Fprintf(writer, "Exit code:\t%d\n", status.ExitCode)
+		fmt.Fprintf(writer, "Started at:\t%s\n", startedAt)
+		fmt.Fprintf(writer, "Restarts:\t%d\n", status.RestartCount)
+		fmt.Fprintf(writer, "Ports:\t%s\n", formatPorts(status.Ports))
+		return writer.Flush()
+	},
+	ValidArgsFunction: unregisterAppCompletionFn,
+}
+
+func init() {
+	rootCmd.AddCommand(statusCmd)
+}
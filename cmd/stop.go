@@ -1,7 +1,10 @@
 package cmd
 
 import (
+	"context"
 	"log/slog"
+	"slices"
+	"strings"
 
 	"github.com/spf13/cobra"
 
@@ -16,14 +19,15 @@ var stopCmd = &cobra.Command{
 	Short: "Stops rocket applications",
 	RunE: func(cmd *cobra.Command, args []string) (err error) {
 		var conn containers.ContainerManager
-		conn, err = containers.Manager()
+		ctx := cmd.Context()
+		conn, err = containerManager()
 		if err != nil {
 			slog.Debug("Failed to connect to podman. Exiting")
 			return
 		}
 		if len(args) > 0 {
 			for _, appName := range args {
-				stopApp(conn, common.CompleteAppName(appName))
+				stopApp(ctx, conn, common.CompleteAppName(appName))
 			}
 		}
 
@@ -36,9 +40,20 @@ func init() {
 	rootCmd.AddCommand(stopCmd)
 }
 
-func stopApp(conn containers.ContainerManager, appName string) (err error) {
+func stopApp(
+	ctx context.Context,
+	conn containers.ContainerManager,
+	appName string,
+) (err error) {
 	slog.Debug("Stopping... " + constants.ApplicationName)
-	err = conn.StopService(appName)
+
+	// unregistered containers like the router are stopped by name
+	appCfg, cfgErr := workspace.GetAppCfg(appName)
+	if cfgErr == nil && appCfg.IsStack() {
+		err = containers.StopStack(ctx, conn, appCfg)
+	} else {
+		err = conn.StopService(ctx, appName)
+	}
 	if err != nil {
 		slog.Debug("Failed to start container. Exiting")
 		return
@@ -55,35 +70,37 @@ func stopAppCompletionFn(cmd *cobra.Command, args []string, toComplete string) (
 	shellDirective = cobra.ShellCompDirectiveNoFileComp
 	runningRockets := []string{}
 	var conn containers.ContainerManager
-	conn, err := containers.Manager()
+	ctx := cmd.Context()
+	conn, err := containerManager()
 	if err != nil {
 		return
 	}
 
-	runningApps, err := conn.ListContainers()
+	workspaceApps, err := workspace.GetApps()
 	if err != nil {
 		return
 	}
 
-	workspaceApps, err := workspace.GetApps()
+	runningApps, err := runningAppNames(ctx, conn, workspaceApps)
 	if err != nil {
 		return
 	}
 
-	for _, runningApp := range runningApps {
+	for runningApp := range runningApps {
 		if _, ok := workspaceApps[runningApp]; ok {
 			runningRockets = append(
 				runningRockets, common.ShortenAppName(runningApp),
 			)
 		}
 	}
+	slices.Sort(runningRockets)
 
 	if len(toComplete) == 0 {
 		return runningRockets, shellDirective
 	}
 
 	for _, rocketApp := range runningRockets {
-		if toComplete == rocketApp[:len(toComplete)] {
+		if strings.HasPrefix(rocketApp, toComplete) {
 			completion = append(completion, rocketApp)
 		}
 	}
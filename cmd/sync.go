@@ -20,6 +20,7 @@ var syncCmd = &cobra.Command{
 			slog.Debug("Failed to sync router configs")
 			return
 		}
+		syncServiceUnits(cmd.Context())
 		return nil
 
 	},
@@ -0,0 +1,225 @@
+package cmd
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"golang.org/x/term"
+
+	"ayayushsharma/rocket/containers"
+	"ayayushsharma/rocket/workspace"
+)
+
+// Resource usage of a registered app, summed over the containers of stacks.
+// Containers of a stack share the host's memory, the limit of the app is the
+// largest one of its containers
+type appUsage struct {
+	App         string  `json:"app"`
+	Containers  int     `json:"containers"`
+	CPUPercent  float64 `json:"cpuPercent"`
+	MemoryUsage uint64  `json:"memoryUsage"`
+	MemoryLimit uint64  `json:"memoryLimit"`
+	NetInput    uint64  `json:"netInput"`
+	NetOutput   uint64  `json:"netOutput"`
+	BlockInput  uint64  `json:"blockInput"`
+	BlockOutput uint64  `json:"blockOutput"`
+	PIDs        uint64  `json:"pids"`
+}
+
+func (u appUsage) memoryPercent() float64 {
+	if u.MemoryLimit == 0 {
+		return 0
+	}
+	return float64(u.MemoryUsage) / float64(u.MemoryLimit) * 100
+}
+
+var topCmd = &cobra.Command{
+	Use:   "top",
+	Short: "Shows CPU, memory, network and disk usage of running apps",
+	Long: `Shows resource usage of the running registered apps, refreshed every
+--interval until interrupted. Containers of stacks are summed up per app.
+
+With --json, or when output is not a terminal, usage is printed once.`,
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
+		ctx := cmd.Context()
+		conn, err := containerManager()
+		if err != nil {
+			return
+		}
+
+		if viper.GetBool("json") {
+			usage, _, err := appUsages(ctx, conn, nil)
+			if err != nil {
+				return err
+			}
+			data, err := json.MarshalIndent(usage, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(data))
+			return nil
+		}
+
+		if !term.IsTerminal(int(os.Stdout.Fd())) {
+			usage, _, err := appUsages(ctx, conn, nil)
+			if err != nil {
+				return err
+			}
+			return printUsage(os.Stdout, usage)
+		}
+
+		interval := viper.GetDuration("interval")
+		if interval <= 0 {
+			return fmt.Errorf("interval must be positive, got %s", interval)
+		}
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		var previous map[string]containers.ContainerStats
+		for {
+			var usage []appUsage
+			usage, previous, err = appUsages(ctx, conn, previous)
+			if errors.Is(err, context.Canceled) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+
+			// clear the screen and move to its top before redrawing
+			fmt.Print("\033[H\033[2J")
+			fmt.Printf("Every %s, press Ctrl-C to stop\n\n", interval)
+			if err = printUsage(os.Stdout, usage); err != nil {
+				return err
+			}
+
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-ticker.C:
+			}
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(topCmd)
+	topCmd.Flags().Bool("json", false, "Print usage once as JSON")
+	topCmd.Flags().Duration("interval", 2*time.Second, "Time between refreshes")
+}
+
+// Samples running containers of registered apps and sums them per app,
+// busiest memory users first. CPU usage is computed against the previous
+// samples when given. Returns the samples for the next call
+func appUsages(
+	ctx context.Context,
+	conn containers.ContainerManager,
+	previous map[string]containers.ContainerStats,
+) (usage []appUsage, samples map[string]containers.ContainerStats, err error) {
+	apps, err := workspace.GetApps()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	managed, err := conn.ListManagedContainers(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	owners := map[string]string{}
+	names := []string{}
+	for _, container := range managed {
+		if _, registered := apps[container.App()]; !registered {
+			continue
+		}
+		if !container.Running() {
+			continue
+		}
+		owners[container.Name] = container.App()
+		names = append(names, container.Name)
+	}
+
+	stats, err := conn.Stats(ctx, names)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	byApp := map[string]*appUsage{}
+	samples = map[string]containers.ContainerStats{}
+	for _, sample := range stats {
+		samples[sample.Name] = sample
+
+		appName := owners[sample.Name]
+		app, ok := byApp[appName]
+		if !ok {
+			app = &appUsage{App: apps[appName].ApplicationName}
+			byApp[appName] = app
+		}
+
+		cpu := sample.CPUPercent
+		if last, ok := previous[sample.Name]; ok {
+			if since, ok := sample.CPUPercentSince(last); ok {
+				cpu = since
+			}
+		}
+
+		app.Containers++
+		app.CPUPercent += cpu
+		app.MemoryUsage += sample.MemoryUsage
+		app.MemoryLimit = max(app.MemoryLimit, sample.MemoryLimit)
+		app.NetInput += sample.NetInput
+		app.NetOutput += sample.NetOutput
+		app.BlockInput += sample.BlockInput
+		app.BlockOutput += sample.BlockOutput
+		app.PIDs += sample.PIDs
+	}
+
+	usage = []appUsage{}
+	for _, app := range byApp {
+		usage = append(usage, *app)
+	}
+	slices.SortFunc(usage, func(a, b appUsage) int {
+		if c := cmp.Compare(b.MemoryUsage, a.MemoryUsage); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.App, b.App)
+	})
+	return usage, samples, nil
+}
+
+func printUsage(out io.Writer, usage []appUsage) error {
+	writer := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "NAME\tCPU %\tMEM USAGE / LIMIT\tMEM %\tNET I/O\tBLOCK I/O\tPIDS")
+	for _, app := range usage {
+		limit := "-"
+		if app.MemoryLimit > 0 {
+			limit = humanSize(int64(app.MemoryLimit))
+		}
+		fmt.Fprintf(
+			writer,
+			"%s\t%.2f%%\t%s / %s\t%.2f%%\t%s / %s\t%s / %s\t%d\n",
+			app.App,
+			app.CPUPercent,
+			humanSize(int64(app.MemoryUsage)),
+			limit,
+			app.memoryPercent(),
+			humanSize(int64(app.NetInput)),
+			humanSize(int64(app.NetOutput)),
+			humanSize(int64(app.BlockInput)),
+			humanSize(int64(app.BlockOutput)),
+			app.PIDs,
+		)
+	}
+	return writer.Flush()
+}
@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"log/slog"
 
 	"github.com/spf13/cobra"
@@ -18,13 +20,14 @@ var unregisterCmd = &cobra.Command{
 	Args:  cobra.MinimumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) (err error) {
 		slog.Debug("Unregistering... " + constants.ApplicationName)
-		conn, err := containers.Manager()
+		ctx := cmd.Context()
+		conn, err := containerManager()
 		if err != nil {
 			slog.Debug("Failed to select application", "error", err)
 			return
 		}
 		containerName := args[0]
-		err = unregisterApplication(conn, common.CompleteAppName(containerName))
+		err = unregisterApplication(ctx, conn, common.CompleteAppName(containerName))
 		if err != nil {
 			slog.Debug("Failed to unregister application", "error", err)
 			return
@@ -45,11 +48,34 @@ func init() {
 }
 
 func unregisterApplication(
+	ctx context.Context,
 	conn containers.ContainerManager,
 	containerName string,
 ) (err error) {
 	force := viper.GetBool("force")
-	err = conn.StopService(containerName)
+
+	appCfg, err := workspace.GetAppCfg(containerName)
+	isStack := appCfg.IsStack()
+	switch {
+	case errors.Is(err, workspace.AppNotRegisteredErr):
+		// containers of apps missing from the workspace are still removed
+		// by name, along with the pod when there is one
+		slog.Debug("App not in workspace, removing containers by name", "app", containerName)
+		appCfg = containers.Config{ContainerName: containerName}
+		isStack, err = conn.PodExists(ctx, appCfg.PodName())
+		if err != nil {
+			slog.Debug("Failed to check if pod exists", "error", err)
+		}
+	case err != nil:
+		slog.Debug("Failed to read app from workspace", "error", err)
+		return
+	}
+
+	if isStack {
+		err = containers.StopStack(ctx, conn, appCfg)
+	} else {
+		err = conn.StopService(ctx, containerName)
+	}
 	if err != nil {
 		slog.Debug("Failed to stop application", "error", err)
 		if !force {
@@ -57,7 +83,11 @@ func unregisterApplication(
 		}
 	}
 
-	err = conn.RemoveContainer(containerName, force)
+	if isStack {
+		err = containers.RemoveStack(ctx, conn, appCfg, force)
+	} else {
+		err = conn.RemoveContainer(ctx, containerName, force)
+	}
 	if err != nil {
 		slog.Debug("Failed to unregister application container", "error", err)
 	}
@@ -67,6 +97,7 @@ func unregisterApplication(
 		slog.Debug("Failed to unregister app from workspace", "error", err)
 		return
 	}
+	syncServiceUnits(ctx)
 
 	return nil
 }
@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"errors"
+	"testing"
+
+	"ayayushsharma/rocket/containers"
+	"ayayushsharma/rocket/workspace"
+)
+
+func TestUnregisterApplication(t *testing.T) {
+	useTempWorkspace(t)
+	conn := newTestManager(t)
+	registerTestApp(t, "rocket-unregister")
+	if err := launchApp(t.Context(), conn, "rocket-unregister"); err != nil {
+		t.Fatalf("launchApp: %v", err)
+	}
+
+	if err := unregisterApplication(t.Context(), conn, "rocket-unregister"); err != nil {
+		t.Fatalf("unregisterApplication: %v", err)
+	}
+
+	exists, err := conn.ContainerExists(t.Context(), "rocket-unregister")
+	if err != nil || exists {
+		t.Errorf("ContainerExists = %v, %v, want false", exists, err)
+	}
+	_, err = workspace.GetAppCfg("rocket-unregister")
+	if !errors.Is(err, workspace.AppNotRegisteredErr) {
+		t.Errorf("GetAppCfg error = %v, want %v", err, workspace.AppNotRegisteredErr)
+	}
+}
+
+func TestUnregisterApplicationStack(t *testing.T) {
+	useTempWorkspace(t)
+	conn := newTestManager(t)
+	appCfg := registerTestApp(t, "rocket-unregister-stack", containers.Config{
+		ImageURL:      "docker.io/library/redis",
+		ImageVersion:  "alpine",
+		ContainerName: "redis",
+	})
+	if err := launchApp(t.Context(), conn, appCfg.ContainerName); err != nil {
+		t.Fatalf("launchApp: %v", err)
+	}
+
+	if err := unregisterApplication(t.Context(), conn, appCfg.ContainerName); err != nil {
+		t.Fatalf("unregisterApplication: %v", err)
+	}
+
+	exists, err := conn.PodExists(t.Context(), appCfg.PodName())
+	if err != nil || exists {
+		t.Errorf("PodExists = %v, %v, want false", exists, err)
+	}
+}
+
+func TestUnregisterApplicationMissingFromWorkspace(t *testing.T) {
+	useTempWorkspace(t)
+	conn := newTestManager(t)
+	appCfg := registerTestApp(t, "rocket-orphan")
+	if err := launchApp(t.Context(), conn, appCfg.ContainerName); err != nil {
+		t.Fatalf("launchApp: %v", err)
+	}
+	if err := workspace.Unregister(appCfg.ContainerName); err != nil {
+		t.Fatal(err)
+	}
+
+	err := unregisterApplication(t.Context(), conn, appCfg.ContainerName)
+	if !errors.Is(err, workspace.AppNotRegisteredErr) {
+		t.Errorf("unregisterApplication error = %v, want %v", err, workspace.AppNotRegisteredErr)
+	}
+
+	exists, err := conn.ContainerExists(t.Context(), appCfg.ContainerName)
+	if err != nil || exists {
+		t.Errorf("ContainerExists = %v, %v, want false", exists, err)
+	}
+}
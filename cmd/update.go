@@ -0,0 +1,161 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"ayayushsharma/rocket/common"
+	"ayayushsharma/rocket/containers"
+	"ayayushsharma/rocket/workspace"
+)
+
+type updateSummary struct {
+	updated  []string
+	upToDate []string
+	skipped  []string
+	failed   []string
+}
+
+var updateCmd = &cobra.Command{
+	Use:   "update [container-name]",
+	Short: "Updates applications to the newest image of their version",
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
+		isUpdateAll := viper.GetBool("all")
+		if !isUpdateAll && len(args) == 0 {
+			return errors.New("supply app names to update or use --all")
+		}
+
+		ctx := cmd.Context()
+		conn, err := containerManager()
+		if err != nil {
+			slog.Debug("Failed to connect to container runtime. Exiting")
+			return
+		}
+
+		appNames := []string{}
+		if isUpdateAll {
+			apps, err := workspace.GetApps()
+			if err != nil {
+				return err
+			}
+			for appName := range apps {
+				appNames = append(appNames, appName)
+			}
+		} else {
+			for _, appName := range args {
+				appNames = append(appNames, common.CompleteAppName(appName))
+			}
+		}
+
+		summary := updateApps(ctx, conn, appNames)
+		summary.print()
+
+		if len(summary.failed) > 0 {
+			return fmt.Errorf("failed to update %d app(s)", len(summary.failed))
+		}
+		return nil
+	},
+	ValidArgsFunction: unregisterAppCompletionFn,
+}
+
+func init() {
+	rootCmd.AddCommand(updateCmd)
+	updateCmd.Flags().Bool("all", false, "Update all the registered apps")
+}
+
+func updateApps(
+	ctx context.Context,
+	conn containers.ContainerManager,
+	appNames []string,
+) (summary updateSummary) {
+	for _, appName := range appNames {
+		// apps left after an interrupt are reported as failed
+		if ctx.Err() != nil {
+			summary.failed = append(summary.failed, appName)
+			continue
+		}
+		updated, err := updateApp(ctx, conn, appName)
+		switch {
+		case errors.Is(err, containers.ContainerDoesntExistErr):
+			summary.skipped = append(summary.skipped, appName)
+		case err != nil:
+			slog.Debug("Failed to update app", "app", appName, "error", err)
+			summary.failed = append(summary.failed, appName)
+		case updated:
+			summary.updated = append(summary.updated, appName)
+		default:
+			summary.upToDate = append(summary.upToDate, appName)
+		}
+	}
+	return summary
+}
+
+func updateApp(
+	ctx context.Context,
+	conn containers.ContainerManager,
+	appName string,
+) (updated bool, err error) {
+	slog.Debug("Updating... " + appName)
+
+	appCfg, err := workspace.GetAppCfg(appName)
+	if err != nil {
+		return false, err
+	}
+
+	// pinned apps only move on to images pinned with `lock --update`
+	pins, err := workspace.GetPins()
+	if err != nil {
+		return false, err
+	}
+
+	appCfg = appCfg.Pin(pins)
+
+	// images are pulled and containers recreated from them right after
+	if err = verifyApp(ctx, appCfg); err != nil {
+		return false, err
+	}
+
+	appCfg, err = prepareEgress(ctx, conn, appCfg)
+	if err != nil {
+		return false, err
+	}
+
+	if appCfg.IsStack() {
+		updated, err = containers.UpdateStack(ctx, conn, appCfg)
+	} else {
+		updated, err = conn.UpdateService(ctx, appCfg)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	slog.Debug("Checked application for update", "application", appName, "updated", updated)
+	return updated, nil
+}
+
+func (s updateSummary) print() {
+	sections := []struct {
+		title string
+		apps  []string
+	}{
+		{"Updated", s.updated},
+		{"Already up to date", s.upToDate},
+		{"Not launched yet, skipped", s.skipped},
+		{"Failed", s.failed},
+	}
+
+	for _, section := range sections {
+		if len(section.apps) == 0 {
+			continue
+		}
+		fmt.Printf("%s:\n", section.title)
+		for _, appName := range section.apps {
+			fmt.Printf("  %s\n", common.ShortenAppName(appName))
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"os"
+	"testing"
+
+	"ayayushsharma/rocket/constants"
+	"ayayushsharma/rocket/containers"
+	"ayayushsharma/rocket/workspace"
+)
+
+func TestUpdateAppStack(t *testing.T) {
+	useTempWorkspace(t)
+	conn := newTestManager(t)
+	appCfg := registerTestApp(t, "rocket-update-stack", containers.Config{
+		ImageURL:      "docker.io/library/redis",
+		ImageVersion:  "alpine",
+		ContainerName: "redis",
+	})
+	if err := launchApp(t.Context(), conn, appCfg.ContainerName); err != nil {
+		t.Fatalf("launchApp: %v", err)
+	}
+
+	updated, err := updateApp(t.Context(), conn, appCfg.ContainerName)
+	if err != nil {
+		t.Fatalf("updateApp: %v", err)
+	}
+	if updated {
+		t.Fatal("stack updated without a new image")
+	}
+
+	// a new image pinned for the web container
+	web := appCfg.StackContainers()[0]
+	created, _ := conn.ContainerConfig(web.ContainerName)
+	conn.SetRemoteImage(created.Image(), "sha256:newer")
+
+	updated, err = updateApp(t.Context(), conn, appCfg.ContainerName)
+	if err != nil {
+		t.Fatalf("updateApp: %v", err)
+	}
+	if !updated {
+		t.Fatal("stack not updated to the new image")
+	}
+
+	recreated, ok := conn.ContainerConfig(web.ContainerName)
+	if !ok {
+		t.Fatalf("web container %q missing after update", web.ContainerName)
+	}
+	if recreated.Pod != appCfg.PodName() {
+		t.Errorf("web container recreated in pod %q, want %q", recreated.Pod, appCfg.PodName())
+	}
+	if state := conn.ContainerState(web.ContainerName); state != containers.MemoryStateRunning {
+		t.Errorf("web container state = %q, want %q", state, containers.MemoryStateRunning)
+	}
+}
+
+func TestUpdateAppNotLaunched(t *testing.T) {
+	useTempWorkspace(t)
+	conn := newTestManager(t)
+	registerTestApp(t, "rocket-update-idle")
+
+	summary := updateApps(t.Context(), conn, []string{"rocket-update-idle"})
+	if len(summary.skipped) != 1 {
+		t.Errorf("summary = %+v, want the app skipped", summary)
+	}
+}
+
+// Trust policy refusing every image
+func rejectAllImages(t *testing.T) {
+	t.Helper()
+
+	policy := `{"default": [{"type": "reject"}]}`
+	if err := os.WriteFile(constants.TrustPolicyJson, []byte(policy), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUpdateAppVerifiesImages(t *testing.T) {
+	useTempWorkspace(t)
+	conn := newTestManager(t)
+	appCfg := registerTestApp(t, "rocket-update-verify")
+	if err := launchApp(t.Context(), conn, appCfg.ContainerName); err != nil {
+		t.Fatalf("launchApp: %v", err)
+	}
+	created, _ := conn.ContainerConfig(appCfg.ContainerName)
+	conn.SetRemoteImage(created.Image(), "sha256:newer")
+	rejectAllImages(t)
+
+	if _, err := updateApp(t.Context(), conn, appCfg.ContainerName); err == nil {
+		t.Fatal("updateApp recreated the app from an image the policy refuses")
+	}
+	current, _ := conn.ContainerConfig(appCfg.ContainerName)
+	if current.ImageDigest != created.ImageDigest {
+		t.Errorf("app moved to %q, want it left on %q", current.ImageDigest, created.ImageDigest)
+	}
+
+	setConfig(t, "insecure-skip-verify", true)
+	updated, err := updateApp(t.Context(), conn, appCfg.ContainerName)
+	if err != nil {
+		t.Fatalf("updateApp with insecure-skip-verify: %v", err)
+	}
+	if !updated {
+		t.Error("app not updated with insecure-skip-verify")
+	}
+}
+
+func TestPinAppVerifiesBeforeRecording(t *testing.T) {
+	useTempWorkspace(t)
+	conn := newTestManager(t)
+	appCfg := registerTestApp(t, "rocket-lock-verify")
+	rejectAllImages(t)
+
+	if _, err := pinApp(t.Context(), conn, appCfg, true); err == nil {
+		t.Fatal("pinApp pinned an image the policy refuses")
+	}
+	pins, err := workspace.GetPins()
+	if err != nil {
+		t.Fatalf("GetPins: %v", err)
+	}
+	if digest, ok := pins[appCfg.ImageRef()]; ok {
+		t.Errorf("refused image recorded in rockets.lock as %q", digest)
+	}
+}
@@ -0,0 +1,252 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"ayayushsharma/rocket/common"
+	"ayayushsharma/rocket/containers"
+	"ayayushsharma/rocket/workspace"
+)
+
+// Minimum time between two relaunches of the same app, so that apps crashing
+// right after start do not flap
+const relaunchInterval = time.Minute
+
+// Runtimes report stops after the death of the container, docker reports the
+// kill of a stop before it too. Deaths of killed containers and crashes only
+// count as such when no stop follows within this time
+const stopSettle = 2 * time.Second
+
+// Reacts to events of managed containers
+type appWatcher struct {
+	// guards the maps below, crashes are settled outside of the event loop
+	mu sync.Mutex
+
+	conn     containers.ContainerManager
+	relaunch bool
+
+	// live state of containers, keyed by container name
+	states map[string]string
+
+	// containers asked to stop, their next death is expected
+	stopping map[string]bool
+
+	// containers sent a signal. A kill is only a stop when a stop follows
+	// the death, `docker kill` reports none
+	killed map[string]bool
+
+	// deaths of killed containers and crashes waiting for a stop to follow
+	pending map[string]containers.Event
+
+	// last relaunch of apps, keyed by workspace entry
+	relaunched map[string]time.Time
+}
+
+var watchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Keeps routes in sync with app containers and reports crashes",
+	Long: `Follows start, stop, die and remove events of app containers until interrupted.
+
+The live state of every app is written to the routes of the home page and
+crashes are reported. With --relaunch, crashed apps whose restart policy is
+"no", or "on-failure" with its retries used up, are launched again, at most
+once a minute.`,
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
+		ctx := cmd.Context()
+		conn, err := containerManager()
+		if err != nil {
+			return
+		}
+
+		watcher := &appWatcher{
+			conn:       conn,
+			relaunch:   viper.GetBool("relaunch"),
+			states:     map[string]string{},
+			stopping:   map[string]bool{},
+			killed:     map[string]bool{},
+			pending:    map[string]containers.Event{},
+			relaunched: map[string]time.Time{},
+		}
+
+		statuses, err := appStatuses(ctx, conn)
+		if err != nil {
+			return
+		}
+		for _, app := range statuses {
+			watcher.states[app.ContainerName] = app.Status.State
+		}
+		if err = workspace.SyncRouterStates(watcher.states); err != nil {
+			return
+		}
+
+		fmt.Println("Watching app containers, press Ctrl-C to stop")
+		err = conn.Events(ctx, func(event containers.Event) {
+			watcher.handle(ctx, event)
+		})
+		if errors.Is(err, context.Canceled) {
+			return nil
+		}
+		return err
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(watchCmd)
+	watchCmd.Flags().Bool(
+		"relaunch", false, "Launch apps again that crashed without a restart policy",
+	)
+	watchCmd.Flags().BoolP("quiet", "q", false, "Do not show image pull progress")
+}
+
+func (w *appWatcher) handle(ctx context.Context, event containers.Event) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	name := common.ShortenAppName(event.Container)
+	at := eventTime(event)
+
+	switch event.Action {
+	case containers.EventStart:
+		delete(w.stopping, event.Container)
+		delete(w.killed, event.Container)
+		delete(w.pending, event.Container)
+		w.states[event.Container] = containers.StateRunning
+		fmt.Printf("%s %s started\n", at, name)
+	case containers.EventStop:
+		if _, ok := w.pending[event.Container]; ok {
+			delete(w.pending, event.Container)
+			fmt.Printf("%s %s stopped\n", at, name)
+			break
+		}
+		w.stopping[event.Container] = true
+	case containers.EventKill:
+		w.killed[event.Container] = true
+	case containers.EventDie:
+		w.states[event.Container] = containers.StateExited
+		killed := w.killed[event.Container]
+		delete(w.killed, event.Container)
+		switch {
+		case w.stopping[event.Container]:
+			delete(w.stopping, event.Container)
+			fmt.Printf("%s %s stopped\n", at, name)
+		case event.Crashed() || killed:
+			w.pending[event.Container] = event
+			time.AfterFunc(stopSettle, func() { w.settle(ctx, event) })
+		default:
+			fmt.Printf("%s %s exited\n", at, name)
+		}
+	case containers.EventRemove:
+		w.states[event.Container] = stateNotCreated
+		delete(w.stopping, event.Container)
+		delete(w.killed, event.Container)
+		fmt.Printf("%s %s removed\n", at, name)
+	}
+
+	if err := workspace.SyncRouterStates(w.states); err != nil {
+		slog.Debug("Failed to write route states", "error", err)
+	}
+}
+
+// Reports the death as a crash unless a stop followed it, relaunching the
+// app when asked to
+func (w *appWatcher) settle(ctx context.Context, event containers.Event) {
+	w.mu.Lock()
+	pending, ok := w.pending[event.Container]
+	if !ok || !pending.Time.Equal(event.Time) {
+		w.mu.Unlock()
+		return
+	}
+	delete(w.pending, event.Container)
+	if !event.Crashed() {
+		fmt.Printf("%s %s exited\n", eventTime(event), common.ShortenAppName(event.Container))
+		w.mu.Unlock()
+		return
+	}
+	fmt.Printf(
+		"%s %s crashed with exit code %d\n",
+		eventTime(event), common.ShortenAppName(event.Container), event.ExitCode,
+	)
+	relaunch := w.shouldRelaunch(ctx, event)
+	w.mu.Unlock()
+
+	if !relaunch || ctx.Err() != nil {
+		return
+	}
+
+	name := common.ShortenAppName(event.App)
+	if err := launchApp(ctx, w.conn, event.App); err != nil {
+		fmt.Printf("Failed to relaunch %s: %v\n", name, err)
+		return
+	}
+	fmt.Printf("Relaunched %s\n", name)
+}
+
+// Whether the app of the crashed container is launched again. Only when
+// relaunching is on and no restart policy of the runtime takes care of it.
+// Called with the lock held
+func (w *appWatcher) shouldRelaunch(ctx context.Context, event containers.Event) bool {
+	if !w.relaunch {
+		return false
+	}
+
+	appCfg, err := workspace.GetAppCfg(event.App)
+	if err != nil {
+		slog.Debug("Crashed container of unregistered app", "app", event.App)
+		return false
+	}
+
+	members := []containers.Config{appCfg}
+	if appCfg.IsStack() {
+		members = appCfg.StackContainers()
+	}
+	for _, member := range members {
+		if member.ContainerName != event.Container {
+			continue
+		}
+		if w.runtimeRestarts(ctx, member) {
+			slog.Debug("Restart policy handles crash", "container", event.Container)
+			return false
+		}
+	}
+
+	if since := time.Since(w.relaunched[event.App]); since < relaunchInterval {
+		fmt.Printf(
+			"Not relaunching %s, it was relaunched %s ago\n",
+			common.ShortenAppName(event.App), since.Round(time.Second),
+		)
+		return false
+	}
+	w.relaunched[event.App] = time.Now()
+	return true
+}
+
+// Whether the runtime restarts the crashed container by its restart policy.
+// Under "on-failure" it gives up once MaxRetries restarts were attempted
+func (w *appWatcher) runtimeRestarts(ctx context.Context, member containers.Config) bool {
+	lifecycle := member.Lifecycle
+	switch {
+	case lifecycle.Restart == containers.RestartNo:
+		return false
+	case lifecycle.Restart != containers.RestartOnFailure || lifecycle.MaxRetries == 0:
+		return true
+	}
+
+	status, err := w.conn.InspectContainer(ctx, member.ContainerName)
+	if err != nil {
+		slog.Debug("Failed to inspect crashed container", "container", member.ContainerName, "error", err)
+		return true
+	}
+	return status.RestartCount < int(lifecycle.MaxRetries)
+}
+
+func eventTime(event containers.Event) string {
+	return event.Time.Local().Format(time.TimeOnly)
+}
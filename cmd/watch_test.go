@@ -0,0 +1,199 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"ayayushsharma/rocket/containers"
+	"ayayushsharma/rocket/workspace"
+)
+
+func newTestWatcher(conn containers.ContainerManager) *appWatcher {
+	return &appWatcher{
+		conn:       conn,
+		relaunch:   true,
+		states:     map[string]string{},
+		stopping:   map[string]bool{},
+		killed:     map[string]bool{},
+		pending:    map[string]containers.Event{},
+		relaunched: map[string]time.Time{},
+	}
+}
+
+func testEvent(action string, appName string, exitCode int) containers.Event {
+	return containers.Event{
+		Action:    action,
+		Container: appName,
+		App:       appName,
+		ExitCode:  exitCode,
+		Time:      time.Now(),
+	}
+}
+
+func TestWatchKillEvents(t *testing.T) {
+	tests := []struct {
+		name      string
+		events    []containers.Event
+		wantCrash bool
+	}{
+		{
+			name: "docker stop",
+			events: []containers.Event{
+				testEvent(containers.EventKill, "rocket-web", 0),
+				testEvent(containers.EventDie, "rocket-web", 143),
+				testEvent(containers.EventStop, "rocket-web", 0),
+			},
+		},
+		{
+			name: "docker stop with clean exit",
+			events: []containers.Event{
+				testEvent(containers.EventKill, "rocket-web", 0),
+				testEvent(containers.EventDie, "rocket-web", 0),
+				testEvent(containers.EventStop, "rocket-web", 0),
+			},
+		},
+		{
+			name: "podman stop",
+			events: []containers.Event{
+				testEvent(containers.EventDie, "rocket-web", 137),
+				testEvent(containers.EventStop, "rocket-web", 0),
+			},
+		},
+		{
+			name: "kill without stop",
+			events: []containers.Event{
+				testEvent(containers.EventKill, "rocket-web", 0),
+				testEvent(containers.EventDie, "rocket-web", 137),
+			},
+			wantCrash: true,
+		},
+		{
+			name: "kill survived then crash",
+			events: []containers.Event{
+				testEvent(containers.EventKill, "rocket-web", 0),
+				testEvent(containers.EventStart, "rocket-web", 0),
+				testEvent(containers.EventDie, "rocket-web", 1),
+			},
+			wantCrash: true,
+		},
+		{
+			name: "crash",
+			events: []containers.Event{
+				testEvent(containers.EventDie, "rocket-web", 1),
+			},
+			wantCrash: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			useTempWorkspace(t)
+			watcher := newTestWatcher(newTestManager(t))
+			watcher.relaunch = false
+
+			for _, event := range test.events {
+				watcher.handle(t.Context(), event)
+			}
+			if len(watcher.killed) != 0 {
+				t.Errorf("kills left after death: %v", watcher.killed)
+			}
+
+			death := test.events[len(test.events)-1]
+			_, pending := watcher.pending["rocket-web"]
+			crashed := pending && death.Crashed()
+			if crashed != test.wantCrash {
+				t.Errorf("crash reported = %v, want %v", crashed, test.wantCrash)
+			}
+			if pending {
+				watcher.settle(t.Context(), watcher.pending["rocket-web"])
+			}
+		})
+	}
+}
+
+func TestShouldRelaunch(t *testing.T) {
+	tests := []struct {
+		name      string
+		lifecycle containers.Lifecycle
+		restarts  int
+		want      bool
+	}{
+		{
+			name:      "no restart policy",
+			lifecycle: containers.Lifecycle{Restart: containers.RestartNo},
+			want:      true,
+		},
+		{
+			name: "default policy",
+		},
+		{
+			name:      "unless stopped",
+			lifecycle: containers.Lifecycle{Restart: containers.RestartUnlessStopped},
+		},
+		{
+			name:      "on failure without limit",
+			lifecycle: containers.Lifecycle{Restart: containers.RestartOnFailure},
+			restarts:  10,
+		},
+		{
+			name:      "on failure with retries left",
+			lifecycle: containers.Lifecycle{Restart: containers.RestartOnFailure, MaxRetries: 3},
+			restarts:  2,
+		},
+		{
+			name:      "on failure with retries used up",
+			lifecycle: containers.Lifecycle{Restart: containers.RestartOnFailure, MaxRetries: 3},
+			restarts:  3,
+			want:      true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			useTempWorkspace(t)
+			conn := newTestManager(t)
+			appCfg := testApp("rocket-watch")
+			appCfg.Lifecycle = test.lifecycle
+			if err := workspace.Register(appCfg); err != nil {
+				t.Fatal(err)
+			}
+			if err := launchApp(t.Context(), conn, appCfg.ContainerName); err != nil {
+				t.Fatalf("launchApp: %v", err)
+			}
+			if err := conn.SetRestartCount(appCfg.ContainerName, test.restarts); err != nil {
+				t.Fatal(err)
+			}
+
+			watcher := newTestWatcher(conn)
+			crash := testEvent(containers.EventDie, appCfg.ContainerName, 1)
+			if got := watcher.shouldRelaunch(t.Context(), crash); got != test.want {
+				t.Errorf("shouldRelaunch() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestShouldRelaunchOnlyOncePerInterval(t *testing.T) {
+	useTempWorkspace(t)
+	conn := newTestManager(t)
+	appCfg := testApp("rocket-watch")
+	appCfg.Lifecycle = containers.Lifecycle{Restart: containers.RestartNo}
+	if err := workspace.Register(appCfg); err != nil {
+		t.Fatal(err)
+	}
+
+	watcher := newTestWatcher(conn)
+	crash := testEvent(containers.EventDie, appCfg.ContainerName, 1)
+	if !watcher.shouldRelaunch(t.Context(), crash) {
+		t.Fatal("crashed app not relaunched")
+	}
+	if watcher.shouldRelaunch(t.Context(), crash) {
+		t.Error("app relaunched twice within the relaunch interval")
+	}
+
+	watcher.relaunch = false
+	watcher.relaunched = map[string]time.Time{}
+	if watcher.shouldRelaunch(t.Context(), crash) {
+		t.Error("app relaunched with relaunching off")
+	}
+}
@@ -83,3 +83,8 @@ func CompleteAppName(appName string) string {
 	}
 	return "rocket-" + appName
 }
+
+// Returns URL on which the router serves the application's subdomain
+func AppURL(subDomain string) string {
+	return fmt.Sprintf("http://%s:%d", subDomain, constants.ApplicationPort)
+}
@@ -10,6 +10,11 @@ const (
 	ApplicationName = "rocket"
 	ApplicationPort = 32100
 	RouterContainer = "rocket-nginx-router"
+
+	// network apps reaching the internet join next to the internal routes
+	// network, and the proxy filtering egress of allowlisted apps
+	EgressNetwork        = "rocket-egress"
+	EgressProxyContainer = "rocket-egress-proxy"
 )
 
 var appVersion string
@@ -21,8 +26,14 @@ var (
 	NginxConfPath     string
 	HomePageDir       string
 	RoutesJson        string
+	EgressProxyConf   string
+	EgressPortsJson   string
 	WorkspaceAppsJson string
+	WorkspaceLockJson string
+	TrustPolicyJson   string
 	RegistriesPath    string
+	SystemdUserDir    string
+	BackupDir         string
 )
 
 func init() {
@@ -48,17 +59,28 @@ func init() {
 	NginxConfPath = filepath.Join(AppStateDir, "nginx/nginx.conf")
 	HomePageDir = filepath.Join(AppStateDir, "home-page")
 	RoutesJson = filepath.Join(HomePageDir, "static/application.json")
+	EgressProxyConf = filepath.Join(AppStateDir, "egress/squid.conf")
+	EgressPortsJson = filepath.Join(AppStateDir, "egress/ports.json")
 
 	WorkspaceAppsJson = filepath.Join(rocketConfigDir, "workspace.rockets.json")
+	WorkspaceLockJson = filepath.Join(rocketConfigDir, "rockets.lock")
+	TrustPolicyJson = filepath.Join(rocketConfigDir, "policy.json")
 	RegistriesPath = filepath.Join(rocketConfigDir, "registries")
+	SystemdUserDir = filepath.Join(userConfigPath, "systemd", "user")
+	BackupDir = filepath.Join(rocketConfigDir, "backups")
 
 	slog.Debug(
 		"Default state paths",
 		"nginx", NginxConfPath,
 		"home", HomePageDir,
 		"routes", RoutesJson,
+		"egress_proxy", EgressProxyConf,
 		"registered_apps", WorkspaceAppsJson,
+		"lock", WorkspaceLockJson,
+		"trust_policy", TrustPolicyJson,
 		"registries", RegistriesPath,
+		"systemd_units", SystemdUserDir,
+		"backups", BackupDir,
 	)
 }
 
@@ -1,5 +1,15 @@
 package containers
 
+import (
+	"context"
+	"log/slog"
+	"maps"
+	"os"
+	"strings"
+
+	"ayayushsharma/rocket/common"
+)
+
 // for loading config
 type Config struct {
 	// name of the application that will be displayed to the user on the GUI
@@ -23,9 +33,11 @@ type Config struct {
 	// the application
 	NetworkName string
 
-	// mounts host directories to containers
-	// mountDirs["HOST_DIR"] = "CONTAINER_DIR"
-	MountDirs map[string]string
+	// outbound traffic the app is allowed. None unless set
+	Egress Egress `json:",omitzero"`
+
+	// bind mounts, named volumes and tmpfs mounts of the container
+	MountDirs MountList
 
 	// bind host ports to container ports
 	// bindPorts["HOST_PORT"] = "CONTAINER_PORT"
@@ -39,4 +51,128 @@ type Config struct {
 
 	// http port to export
 	ExposeHttpPort int
+
+	// CPU, memory and process limits of the container
+	Resources Resources
+
+	// check run by the runtime to determine health of the app
+	Healthcheck Healthcheck
+
+	// restart policy, stop signal and stop timeout of the app
+	Lifecycle Lifecycle `json:",omitzero"`
+
+	// user namespace, user and groups the app runs with e.g. to keep files it
+	// writes to bind mounts owned by the host user
+	Identity Identity `json:",omitzero"`
+
+	// additional containers like databases or caches run next to the app in
+	// one pod. Only the app container itself is routed
+	Stack []Config `json:",omitempty"`
+
+	// pod the container joins. Set for containers of a stack
+	Pod string `json:",omitempty"`
+
+	// app is only launched by hand. Boot units installed by `rocket service`
+	// leave it out
+	ManualStart bool `json:",omitempty"`
+
+	// path of the sigstore (cosign) public key the image must be signed
+	// with. Trust policy of the workspace applies when empty
+	SignatureKey string `json:",omitempty"`
+
+	// digest the image version is pinned to. Comes from the lock file of the
+	// workspace and is never stored with the config itself
+	ImageDigest string `json:"-"`
+
+	// URL of the egress proxy port assigned to the app. Set on launch for
+	// apps with an allowlist and never stored with the config itself
+	EgressProxy string `json:"-"`
+
+	// proxy networks of the apps the egress proxy serves. Only set for the
+	// proxy itself
+	ProxyNetworks []string `json:"-"`
+}
+
+// Image reference with version, as written in the workspace
+func (c Config) ImageRef() string {
+	version := strings.Trim(c.ImageVersion, " ")
+	if version == "" {
+		return c.ImageURL
+	}
+	return c.ImageURL + ":" + version
+}
+
+// Full image reference the container is created from. Pinned images are
+// referenced by digest
+func (c Config) Image() string {
+	if c.ImageDigest != "" {
+		return c.ImageURL + "@" + c.ImageDigest
+	}
+	return c.ImageRef()
+}
+
+// Environment variables injected into every app so that it knows the address
+// it is served on
+const (
+	EnvAppName   = "ROCKET_APP_NAME"
+	EnvSubDomain = "ROCKET_SUBDOMAIN"
+	EnvPublicURL = "ROCKET_PUBLIC_URL"
+)
+
+// Environment of the container. Rocket and proxy variables are injected first, then
+// passthrough variables from host and finally the static values, so that a
+// workspace can override anything
+func (c Config) Environment() map[string]string {
+	env := map[string]string{
+		EnvAppName: c.ApplicationName,
+	}
+	if c.SubDomain != "" {
+		env[EnvSubDomain] = c.SubDomain
+		env[EnvPublicURL] = common.AppURL(c.SubDomain)
+	}
+	maps.Copy(env, c.proxyEnvironment())
+
+	for _, hostVar := range c.EnvVars {
+		value, ok := os.LookupEnv(hostVar)
+		if !ok {
+			slog.Debug("Host env var not set, skipping", "name", hostVar)
+			continue
+		}
+		env[hostVar] = value
+	}
+
+	for key, value := range c.EnvValues {
+		env[key] = value
+	}
+
+	return env
+}
+
+// Replaces existing container with a fresh one created from options.
+// Container is started again only if it was running before
+func recreateService(
+	ctx context.Context,
+	conn ContainerManager,
+	options Config,
+	wasRunning bool,
+) (err error) {
+	if wasRunning {
+		if err = conn.StopService(ctx, options.ContainerName); err != nil {
+			return err
+		}
+	}
+
+	if err = conn.RemoveContainer(ctx, options.ContainerName, false); err != nil {
+		return err
+	}
+
+	if err = conn.CreateContainer(ctx, options); err != nil {
+		return err
+	}
+	slog.Debug("Recreated container", "name", options.ContainerName)
+
+	if wasRunning {
+		return conn.StartService(ctx, options.ContainerName)
+	}
+	return nil
 }
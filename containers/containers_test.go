@@ -0,0 +1,86 @@
+package containers
+
+import (
+	"maps"
+	"testing"
+
+	"ayayushsharma/rocket/common"
+	"ayayushsharma/rocket/constants"
+)
+
+func TestEnvironmentPrecedence(t *testing.T) {
+	t.Setenv("ROCKET_TEST_TOKEN", "from host")
+	t.Setenv(EnvSubDomain, "host.app.localhost")
+	t.Setenv("HTTP_PROXY", "http://host-proxy:3128")
+
+	tests := []struct {
+		name string
+		cfg  Config
+		want map[string]string
+	}{
+		{
+			name: "injected only",
+			cfg: Config{
+				ApplicationName: "web",
+				SubDomain:       "web.app.localhost",
+			},
+			want: map[string]string{
+				EnvAppName:   "web",
+				EnvSubDomain: "web.app.localhost",
+				EnvPublicURL: common.AppURL("web.app.localhost"),
+			},
+		},
+		{
+			name: "host passthrough overrides injected",
+			cfg: Config{
+				ApplicationName: "web",
+				SubDomain:       "web.app.localhost",
+				EgressProxy:     "http://rocket-egress:3128",
+				EnvVars:         []string{EnvSubDomain, "HTTP_PROXY", "ROCKET_TEST_UNSET"},
+			},
+			want: map[string]string{
+				EnvAppName:    "web",
+				EnvSubDomain:  "host.app.localhost",
+				EnvPublicURL:  common.AppURL("web.app.localhost"),
+				"HTTP_PROXY":  "http://host-proxy:3128",
+				"HTTPS_PROXY": "http://rocket-egress:3128",
+				"http_proxy":  "http://rocket-egress:3128",
+				"https_proxy": "http://rocket-egress:3128",
+				"NO_PROXY":    "localhost,127.0.0.1," + constants.RouterContainer,
+				"no_proxy":    "localhost,127.0.0.1," + constants.RouterContainer,
+			},
+		},
+		{
+			name: "inline values override everything",
+			cfg: Config{
+				ApplicationName: "web",
+				EgressProxy:     "http://rocket-egress:3128",
+				EnvVars:         []string{"ROCKET_TEST_TOKEN", "HTTP_PROXY"},
+				EnvValues: map[string]string{
+					EnvAppName:          "renamed",
+					"ROCKET_TEST_TOKEN": "from workspace",
+					"HTTP_PROXY":        "http://workspace-proxy:3128",
+					"https_proxy":       "",
+				},
+			},
+			want: map[string]string{
+				EnvAppName:          "renamed",
+				"ROCKET_TEST_TOKEN": "from workspace",
+				"HTTP_PROXY":        "http://workspace-proxy:3128",
+				"HTTPS_PROXY":       "http://rocket-egress:3128",
+				"http_proxy":        "http://rocket-egress:3128",
+				"https_proxy":       "",
+				"NO_PROXY":          "localhost,127.0.0.1," + constants.RouterContainer,
+				"no_proxy":          "localhost,127.0.0.1," + constants.RouterContainer,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.cfg.Environment(); !maps.Equal(got, test.want) {
+				t.Errorf("Environment() = %v\nwant %v", got, test.want)
+			}
+		})
+	}
+}
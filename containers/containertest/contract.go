@@ -0,0 +1,736 @@
+// Contract every ContainerManager backend is expected to satisfy.
+//
+// Backends run it from their own tests:
+//
+//	func TestPodman(t *testing.T) {
+//		containertest.Run(t, func(t *testing.T) containers.ContainerManager {
+//			conn, err := containers.Manager(containers.ConnectOptions{})
+//			if err != nil {
+//				t.Skip("podman not reachable")
+//			}
+//			return conn
+//		})
+//	}
+
+package containertest
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/netip"
+	"path"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"ayayushsharma/rocket/containers"
+)
+
+// Small image used for exercising real runtimes
+const (
+	ImageURL     = "docker.io/library/busybox"
+	ImageVersion = "latest"
+)
+
+type Factory func(t *testing.T) containers.ContainerManager
+
+// Runs the contract against managers built by newManager.
+// A fresh manager is requested for every sub test.
+func Run(t *testing.T, newManager Factory) {
+	t.Helper()
+
+	t.Run("Images", func(t *testing.T) {
+		testImages(t, newManager(t))
+	})
+	t.Run("Networks", func(t *testing.T) {
+		testNetworks(t, newManager(t))
+	})
+	t.Run("ContainerLifecycle", func(t *testing.T) {
+		testContainerLifecycle(t, newManager(t))
+	})
+	t.Run("CreateIsIdempotent", func(t *testing.T) {
+		testCreateIsIdempotent(t, newManager(t))
+	})
+	t.Run("UpdateUnchangedImage", func(t *testing.T) {
+		testUpdateUnchangedImage(t, newManager(t))
+	})
+	t.Run("MissingContainer", func(t *testing.T) {
+		testMissingContainer(t, newManager(t))
+	})
+	t.Run("InspectContainer", func(t *testing.T) {
+		testInspectContainer(t, newManager(t))
+	})
+	t.Run("Logs", func(t *testing.T) {
+		testLogs(t, newManager(t))
+	})
+	t.Run("Exec", func(t *testing.T) {
+		testExec(t, newManager(t))
+	})
+	t.Run("Volumes", func(t *testing.T) {
+		testVolumes(t, newManager(t))
+	})
+	t.Run("Pods", func(t *testing.T) {
+		testPods(t, newManager(t))
+	})
+	t.Run("Events", func(t *testing.T) {
+		testEvents(t, newManager(t))
+	})
+	t.Run("Stats", func(t *testing.T) {
+		testStats(t, newManager(t))
+	})
+	t.Run("VolumeExportImport", func(t *testing.T) {
+		testVolumeExportImport(t, newManager(t))
+	})
+	t.Run("CancelledContext", func(t *testing.T) {
+		testCancelledContext(t, newManager(t))
+	})
+}
+
+// unique names so that runs against live runtimes do not collide
+func uniqueName(kind string) string {
+	return fmt.Sprintf("rocket-contract-%s-%d", kind, time.Now().UnixNano())
+}
+
+func image() string {
+	return ImageURL + ":" + ImageVersion
+}
+
+func pullImage(t *testing.T, conn containers.ContainerManager) {
+	t.Helper()
+
+	if err := conn.PullImage(t.Context(), image(), containers.PullOptions{}); err != nil {
+		t.Fatalf("PullImage(%q): %v", image(), err)
+	}
+}
+
+func createNetwork(t *testing.T, conn containers.ContainerManager) string {
+	t.Helper()
+
+	networkName := uniqueName("net")
+	if err := conn.CreateNetwork(t.Context(), networkName, true); err != nil {
+		t.Fatalf("CreateNetwork(%q): %v", networkName, err)
+	}
+	return networkName
+}
+
+func createContainer(
+	t *testing.T,
+	conn containers.ContainerManager,
+	networkName string,
+) containers.Config {
+	t.Helper()
+
+	return createContainerWith(t, conn, containerConfig(networkName))
+}
+
+func containerConfig(networkName string) containers.Config {
+	return containers.Config{
+		ApplicationName: "contract",
+		ContainerName:   uniqueName("ctr"),
+		ImageURL:        ImageURL,
+		ImageVersion:    ImageVersion,
+		SubDomain:       "contract.localhost",
+		NetworkName:     networkName,
+	}
+}
+
+func createContainerWith(
+	t *testing.T,
+	conn containers.ContainerManager,
+	config containers.Config,
+) containers.Config {
+	t.Helper()
+
+	if err := conn.CreateContainer(t.Context(), config); err != nil {
+		t.Fatalf("CreateContainer(%q): %v", config.ContainerName, err)
+	}
+	t.Cleanup(func() {
+		// context of the test is already cancelled when cleanups run
+		_ = conn.RemoveContainer(context.Background(), config.ContainerName, true)
+	})
+	return config
+}
+
+func testImages(t *testing.T, conn containers.ContainerManager) {
+	pullImage(t, conn)
+
+	exists, err := conn.ImageExists(t.Context(), image())
+	if err != nil {
+		t.Fatalf("ImageExists: %v", err)
+	}
+	if !exists {
+		t.Fatalf("ImageExists(%q) = false after pull", image())
+	}
+
+	exists, err = conn.ImageExists(t.Context(), uniqueName("image")+":missing")
+	if err != nil {
+		t.Fatalf("ImageExists on missing image: %v", err)
+	}
+	if exists {
+		t.Fatal("ImageExists = true for an image never pulled")
+	}
+}
+
+func testNetworks(t *testing.T, conn containers.ContainerManager) {
+	networkName := uniqueName("net")
+
+	exists, err := conn.NetworkExists(t.Context(), networkName)
+	if err != nil {
+		t.Fatalf("NetworkExists: %v", err)
+	}
+	if exists {
+		t.Fatalf("NetworkExists(%q) = true before creation", networkName)
+	}
+
+	if err := conn.CreateNetwork(t.Context(), networkName, true); err != nil {
+		t.Fatalf("CreateNetwork(%q): %v", networkName, err)
+	}
+
+	exists, err = conn.NetworkExists(t.Context(), networkName)
+	if err != nil {
+		t.Fatalf("NetworkExists: %v", err)
+	}
+	if !exists {
+		t.Fatalf("NetworkExists(%q) = false after creation", networkName)
+	}
+
+	networks, err := conn.ListNetworks(t.Context())
+	if err != nil {
+		t.Fatalf("ListNetworks: %v", err)
+	}
+	if !slices.Contains(networks, networkName) {
+		t.Fatalf("ListNetworks() = %v, missing %q", networks, networkName)
+	}
+
+	subnets, err := conn.NetworkSubnets(t.Context(), networkName)
+	if err != nil {
+		t.Fatalf("NetworkSubnets: %v", err)
+	}
+	if len(subnets) == 0 {
+		t.Fatalf("NetworkSubnets(%q) reports no subnet", networkName)
+	}
+	for _, subnet := range subnets {
+		if _, err := netip.ParsePrefix(subnet); err != nil {
+			t.Fatalf("NetworkSubnets(%q) = %v: %v", networkName, subnets, err)
+		}
+	}
+}
+
+func testContainerLifecycle(t *testing.T, conn containers.ContainerManager) {
+	pullImage(t, conn)
+	networkName := createNetwork(t, conn)
+	config := createContainer(t, conn, networkName)
+	name := config.ContainerName
+
+	exists, err := conn.ContainerExists(t.Context(), name)
+	if err != nil {
+		t.Fatalf("ContainerExists: %v", err)
+	}
+	if !exists {
+		t.Fatalf("ContainerExists(%q) = false after creation", name)
+	}
+
+	running, err := conn.ListContainers(t.Context())
+	if err != nil {
+		t.Fatalf("ListContainers: %v", err)
+	}
+	if slices.Contains(running, name) {
+		t.Fatalf("ListContainers() lists %q before it was started", name)
+	}
+
+	if err := conn.StartService(t.Context(), name); err != nil {
+		t.Fatalf("StartService(%q): %v", name, err)
+	}
+
+	running, err = conn.ListContainers(t.Context())
+	if err != nil {
+		t.Fatalf("ListContainers: %v", err)
+	}
+	if !slices.Contains(running, name) {
+		t.Fatalf("ListContainers() = %v, missing started %q", running, name)
+	}
+
+	if err := conn.StopService(t.Context(), name); err != nil {
+		t.Fatalf("StopService(%q): %v", name, err)
+	}
+
+	running, err = conn.ListContainers(t.Context())
+	if err != nil {
+		t.Fatalf("ListContainers: %v", err)
+	}
+	if slices.Contains(running, name) {
+		t.Fatalf("ListContainers() still lists stopped %q", name)
+	}
+
+	if err := conn.RemoveContainer(t.Context(), name, false); err != nil {
+		t.Fatalf("RemoveContainer(%q): %v", name, err)
+	}
+
+	exists, err = conn.ContainerExists(t.Context(), name)
+	if err != nil {
+		t.Fatalf("ContainerExists: %v", err)
+	}
+	if exists {
+		t.Fatalf("ContainerExists(%q) = true after removal", name)
+	}
+}
+
+func testCreateIsIdempotent(t *testing.T, conn containers.ContainerManager) {
+	pullImage(t, conn)
+	networkName := createNetwork(t, conn)
+	config := createContainer(t, conn, networkName)
+
+	if err := conn.CreateContainer(t.Context(), config); err != nil {
+		t.Fatalf("second CreateContainer(%q): %v", config.ContainerName, err)
+	}
+}
+
+func testUpdateUnchangedImage(t *testing.T, conn containers.ContainerManager) {
+	pullImage(t, conn)
+	networkName := createNetwork(t, conn)
+	config := createContainer(t, conn, networkName)
+
+	updated, err := conn.UpdateService(t.Context(), config)
+	if err != nil {
+		t.Fatalf("UpdateService(%q): %v", config.ContainerName, err)
+	}
+	if updated {
+		t.Fatalf("UpdateService(%q) recreated container of unchanged image", config.ContainerName)
+	}
+}
+
+func testMissingContainer(t *testing.T, conn containers.ContainerManager) {
+	name := uniqueName("missing")
+
+	exists, err := conn.ContainerExists(t.Context(), name)
+	if err != nil {
+		t.Fatalf("ContainerExists: %v", err)
+	}
+	if exists {
+		t.Fatalf("ContainerExists(%q) = true for unknown container", name)
+	}
+
+	if err := conn.StartService(t.Context(), name); err == nil {
+		t.Fatalf("StartService(%q) succeeded for unknown container", name)
+	}
+	if err := conn.StopService(t.Context(), name); err == nil {
+		t.Fatalf("StopService(%q) succeeded for unknown container", name)
+	}
+	if err := conn.RemoveContainer(t.Context(), name, false); err == nil {
+		t.Fatalf("RemoveContainer(%q) succeeded for unknown container", name)
+	}
+
+	missing := containers.Config{
+		ContainerName: name,
+		ImageURL:      ImageURL,
+		ImageVersion:  ImageVersion,
+	}
+	_, err = conn.UpdateService(t.Context(), missing)
+	if !errors.Is(err, containers.ContainerDoesntExistErr) {
+		t.Fatalf("UpdateService(%q) = %v, want ContainerDoesntExistErr", name, err)
+	}
+}
+
+func testInspectContainer(t *testing.T, conn containers.ContainerManager) {
+	pullImage(t, conn)
+	networkName := createNetwork(t, conn)
+	config := createContainer(t, conn, networkName)
+	name := config.ContainerName
+
+	status, err := conn.InspectContainer(t.Context(), name)
+	if err != nil {
+		t.Fatalf("InspectContainer(%q): %v", name, err)
+	}
+	if status.Running() {
+		t.Fatalf("InspectContainer(%q) reports running before start, state %q", name, status.State)
+	}
+	if status.ImageDigest == "" {
+		t.Fatalf("InspectContainer(%q) reports no image", name)
+	}
+
+	if err := conn.StartService(t.Context(), name); err != nil {
+		t.Fatalf("StartService(%q): %v", name, err)
+	}
+	status, err = conn.InspectContainer(t.Context(), name)
+	if err != nil {
+		t.Fatalf("InspectContainer(%q): %v", name, err)
+	}
+	if !status.Running() {
+		t.Fatalf("InspectContainer(%q) state = %q after start", name, status.State)
+	}
+	if !slices.Contains(status.Networks, networkName) {
+		t.Fatalf("InspectContainer(%q) networks = %v, missing %q", name, status.Networks, networkName)
+	}
+	if status.StartedAt.IsZero() {
+		t.Fatalf("InspectContainer(%q) reports no start time", name)
+	}
+
+	_, err = conn.InspectContainer(t.Context(), uniqueName("missing"))
+	if !errors.Is(err, containers.ContainerDoesntExistErr) {
+		t.Fatalf("InspectContainer on unknown container = %v, want ContainerDoesntExistErr", err)
+	}
+}
+
+func testLogs(t *testing.T, conn containers.ContainerManager) {
+	pullImage(t, conn)
+	networkName := createNetwork(t, conn)
+	config := createContainer(t, conn, networkName)
+
+	var stdout, stderr bytes.Buffer
+	options := containers.LogOptions{Tail: -1}
+	if err := conn.Logs(t.Context(), config.ContainerName, options, &stdout, &stderr); err != nil {
+		t.Fatalf("Logs(%q): %v", config.ContainerName, err)
+	}
+
+	name := uniqueName("missing")
+	if err := conn.Logs(t.Context(), name, options, &stdout, &stderr); err == nil {
+		t.Fatalf("Logs(%q) succeeded for unknown container", name)
+	}
+}
+
+func testExec(t *testing.T, conn containers.ContainerManager) {
+	pullImage(t, conn)
+	networkName := createNetwork(t, conn)
+	config := createContainer(t, conn, networkName)
+	name := config.ContainerName
+
+	options := containers.ExecOptions{
+		Cmd:    []string{"true"},
+		Stdout: io.Discard,
+		Stderr: io.Discard,
+	}
+	if _, err := conn.Exec(t.Context(), name, options); err == nil {
+		t.Fatalf("Exec in %q succeeded before it was started", name)
+	}
+
+	if err := conn.StartService(t.Context(), name); err != nil {
+		t.Fatalf("StartService(%q): %v", name, err)
+	}
+	exitCode, err := conn.Exec(t.Context(), name, options)
+	if err != nil {
+		t.Fatalf("Exec in %q: %v", name, err)
+	}
+	if exitCode != 0 {
+		t.Fatalf("Exec in %q exited with %d, want 0", name, exitCode)
+	}
+
+	missing := uniqueName("missing")
+	if _, err := conn.Exec(t.Context(), missing, options); err == nil {
+		t.Fatalf("Exec in %q succeeded for unknown container", missing)
+	}
+}
+
+// Creates a container mounting a fresh volume at /data
+func createVolumeContainer(
+	t *testing.T,
+	conn containers.ContainerManager,
+) (config containers.Config, volumeName string) {
+	t.Helper()
+
+	pullImage(t, conn)
+	config = containerConfig(createNetwork(t, conn))
+	volumeName = uniqueName("vol")
+	config.MountDirs = containers.MountList{{
+		Type:        containers.MountVolume,
+		Source:      volumeName,
+		Destination: "/data",
+	}}
+	t.Cleanup(func() {
+		_ = conn.RemoveVolume(context.Background(), volumeName, true)
+	})
+	return createContainerWith(t, conn, config), volumeName
+}
+
+func testVolumes(t *testing.T, conn containers.ContainerManager) {
+	config, volumeName := createVolumeContainer(t, conn)
+
+	volumes, err := conn.ListManagedVolumes(t.Context())
+	if err != nil {
+		t.Fatalf("ListManagedVolumes: %v", err)
+	}
+	index := slices.IndexFunc(volumes, func(volume containers.ManagedVolume) bool {
+		return volume.Name == volumeName
+	})
+	if index < 0 {
+		t.Fatalf("ListManagedVolumes() = %v, missing %q", volumes, volumeName)
+	}
+	if app := volumes[index].App(); app != config.ContainerName {
+		t.Fatalf("volume %q labelled with app %q, want %q", volumeName, app, config.ContainerName)
+	}
+
+	if err := conn.RemoveVolume(t.Context(), volumeName, false); err == nil {
+		t.Fatalf("RemoveVolume(%q) removed a volume in use", volumeName)
+	}
+	if err := conn.RemoveContainer(t.Context(), config.ContainerName, false); err != nil {
+		t.Fatalf("RemoveContainer(%q): %v", config.ContainerName, err)
+	}
+	if err := conn.RemoveVolume(t.Context(), volumeName, false); err != nil {
+		t.Fatalf("RemoveVolume(%q): %v", volumeName, err)
+	}
+
+	volumes, err = conn.ListManagedVolumes(t.Context())
+	if err != nil {
+		t.Fatalf("ListManagedVolumes: %v", err)
+	}
+	for _, volume := range volumes {
+		if volume.Name == volumeName {
+			t.Fatalf("ListManagedVolumes() still lists removed %q", volumeName)
+		}
+	}
+}
+
+func testPods(t *testing.T, conn containers.ContainerManager) {
+	pullImage(t, conn)
+	config := containerConfig(createNetwork(t, conn))
+	config.Stack = []containers.Config{{
+		ApplicationName: "sidecar",
+		ImageURL:        ImageURL,
+		ImageVersion:    ImageVersion,
+	}}
+	podName := config.PodName()
+
+	exists, err := conn.PodExists(t.Context(), podName)
+	if errors.Is(err, containers.PodsUnsupportedErr) {
+		t.Skip("runtime does not support pods")
+	}
+	if err != nil {
+		t.Fatalf("PodExists: %v", err)
+	}
+	if exists {
+		t.Fatalf("PodExists(%q) = true before creation", podName)
+	}
+
+	if err := containers.CreateStack(t.Context(), conn, config); err != nil {
+		t.Fatalf("CreateStack(%q): %v", config.ContainerName, err)
+	}
+	t.Cleanup(func() {
+		_ = conn.RemovePod(context.Background(), podName, true)
+	})
+	exists, err = conn.PodExists(t.Context(), podName)
+	if err != nil {
+		t.Fatalf("PodExists: %v", err)
+	}
+	if !exists {
+		t.Fatalf("PodExists(%q) = false after creation", podName)
+	}
+	managed, err := conn.ListManagedPods(t.Context())
+	if err != nil {
+		t.Fatalf("ListManagedPods: %v", err)
+	}
+	listed := slices.ContainsFunc(managed, func(pod containers.ManagedPod) bool {
+		return pod.Name == podName && pod.App() == config.ContainerName
+	})
+	if !listed {
+		t.Fatalf("ListManagedPods() = %v, missing pod %q", managed, podName)
+	}
+
+	if err := conn.StartPod(t.Context(), podName); err != nil {
+		t.Fatalf("StartPod(%q): %v", podName, err)
+	}
+	running, err := conn.ListContainers(t.Context())
+	if err != nil {
+		t.Fatalf("ListContainers: %v", err)
+	}
+	for _, member := range config.StackContainers() {
+		if !slices.Contains(running, member.ContainerName) {
+			t.Fatalf("ListContainers() = %v, missing pod member %q", running, member.ContainerName)
+		}
+	}
+
+	if err := conn.StopPod(t.Context(), podName); err != nil {
+		t.Fatalf("StopPod(%q): %v", podName, err)
+	}
+	if err := conn.RemovePod(t.Context(), podName, false); err != nil {
+		t.Fatalf("RemovePod(%q): %v", podName, err)
+	}
+	for _, member := range config.StackContainers() {
+		exists, err := conn.ContainerExists(t.Context(), member.ContainerName)
+		if err != nil {
+			t.Fatalf("ContainerExists: %v", err)
+		}
+		if exists {
+			t.Fatalf("pod member %q outlived RemovePod", member.ContainerName)
+		}
+	}
+	exists, err = conn.PodExists(t.Context(), podName)
+	if err != nil {
+		t.Fatalf("PodExists: %v", err)
+	}
+	if exists {
+		t.Fatalf("PodExists(%q) = true after removal", podName)
+	}
+}
+
+func testEvents(t *testing.T, conn containers.ContainerManager) {
+	pullImage(t, conn)
+	networkName := createNetwork(t, conn)
+	config := createContainer(t, conn, networkName)
+	name := config.ContainerName
+
+	ctx, cancel := context.WithCancel(t.Context())
+	started := make(chan containers.Event, 1)
+	watched := make(chan error, 1)
+	go func() {
+		watched <- conn.Events(ctx, func(event containers.Event) {
+			if event.Container == name && event.Action == containers.EventStart {
+				select {
+				case started <- event:
+				default:
+				}
+			}
+		})
+	}()
+
+	// the watch subscribes in the background, the container is restarted
+	// until its start is seen
+	var event containers.Event
+	for attempt := 0; ; attempt++ {
+		if err := conn.StartService(t.Context(), name); err != nil {
+			t.Fatalf("StartService(%q): %v", name, err)
+		}
+		select {
+		case event = <-started:
+		case err := <-watched:
+			t.Fatalf("Events returned before it was cancelled: %v", err)
+		case <-time.After(500 * time.Millisecond):
+			if attempt == 20 {
+				t.Fatalf("no start event of %q", name)
+			}
+			if err := conn.StopService(t.Context(), name); err != nil {
+				t.Fatalf("StopService(%q): %v", name, err)
+			}
+			continue
+		}
+		break
+	}
+	if event.App != name {
+		t.Fatalf("start event of %q owned by app %q", name, event.App)
+	}
+
+	cancel()
+	select {
+	case err := <-watched:
+		if err != nil && !errors.Is(err, context.Canceled) {
+			t.Fatalf("Events after cancel = %v, want nil or context.Canceled", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("Events did not return once cancelled")
+	}
+}
+
+func testStats(t *testing.T, conn containers.ContainerManager) {
+	pullImage(t, conn)
+	networkName := createNetwork(t, conn)
+	config := createContainer(t, conn, networkName)
+	name := config.ContainerName
+
+	if err := conn.StartService(t.Context(), name); err != nil {
+		t.Fatalf("StartService(%q): %v", name, err)
+	}
+	stats, err := conn.Stats(t.Context(), []string{name})
+	if err != nil {
+		t.Fatalf("Stats(%q): %v", name, err)
+	}
+	if len(stats) != 1 || stats[0].Name != name {
+		t.Fatalf("Stats(%q) = %v, want one sample of it", name, stats)
+	}
+	if stats[0].Read.IsZero() {
+		t.Fatalf("Stats(%q) sample has no read time", name)
+	}
+
+	stats, err = conn.Stats(t.Context(), nil)
+	if err != nil {
+		t.Fatalf("Stats of no containers: %v", err)
+	}
+	if len(stats) != 0 {
+		t.Fatalf("Stats of no containers = %v, want none", stats)
+	}
+
+	missing := uniqueName("missing")
+	if _, err := conn.Stats(t.Context(), []string{missing}); err == nil {
+		t.Fatalf("Stats(%q) succeeded for unknown container", missing)
+	}
+}
+
+func testVolumeExportImport(t *testing.T, conn containers.ContainerManager) {
+	_, volumeName := createVolumeContainer(t, conn)
+
+	contents := []byte("restored")
+	var archive bytes.Buffer
+	writer := tar.NewWriter(&archive)
+	header := &tar.Header{
+		Name:    "hello.txt",
+		Mode:    0644,
+		Size:    int64(len(contents)),
+		ModTime: time.Now(),
+	}
+	if err := writer.WriteHeader(header); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := writer.Write(contents); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := conn.ImportVolume(t.Context(), volumeName, &archive); err != nil {
+		t.Fatalf("ImportVolume(%q): %v", volumeName, err)
+	}
+
+	var exported bytes.Buffer
+	if err := conn.ExportVolume(t.Context(), volumeName, &exported); err != nil {
+		t.Fatalf("ExportVolume(%q): %v", volumeName, err)
+	}
+	reader := tar.NewReader(&exported)
+	for {
+		header, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			t.Fatalf("ExportVolume(%q) is missing the imported hello.txt", volumeName)
+		}
+		if err != nil {
+			t.Fatalf("ExportVolume(%q) wrote an invalid archive: %v", volumeName, err)
+		}
+		if path.Clean(strings.TrimPrefix(header.Name, "/")) != "hello.txt" {
+			continue
+		}
+		data, err := io.ReadAll(reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(data, contents) {
+			t.Fatalf("exported hello.txt = %q, want %q", data, contents)
+		}
+		break
+	}
+
+	missing := uniqueName("missing")
+	err := conn.ExportVolume(t.Context(), missing, io.Discard)
+	if !errors.Is(err, containers.VolumeDoesntExistErr) {
+		t.Fatalf("ExportVolume(%q) = %v, want VolumeDoesntExistErr", missing, err)
+	}
+	err = conn.ImportVolume(t.Context(), missing, bytes.NewReader(nil))
+	if !errors.Is(err, containers.VolumeDoesntExistErr) {
+		t.Fatalf("ImportVolume(%q) = %v, want VolumeDoesntExistErr", missing, err)
+	}
+}
+
+func testCancelledContext(t *testing.T, conn containers.ContainerManager) {
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	if err := conn.PullImage(ctx, image(), containers.PullOptions{}); err == nil {
+		t.Fatal("PullImage succeeded with cancelled context")
+	}
+	if _, err := conn.ContainerExists(ctx, uniqueName("ctr")); err == nil {
+		t.Fatal("ContainerExists succeeded with cancelled context")
+	}
+	if _, err := conn.ListNetworks(ctx); err == nil {
+		t.Fatal("ListNetworks succeeded with cancelled context")
+	}
+}
@@ -0,0 +1,1600 @@
+package containers
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultDockerHost = "unix:///var/run/docker.sock"
+
+// Docker Engine API paths are issued against this placeholder host, the real
+// destination is decided by the dialer of the http client
+const dockerAPIBase = "http://docker"
+
+type DockerContext struct {
+	client   *http.Client
+	base     string
+	dial     func(ctx context.Context) (net.Conn, error)
+	timeouts Timeouts
+}
+
+type dockerAPIError struct {
+	Message string `json:"message"`
+}
+
+type dockerStreamMessage struct {
+	Id             string               `json:"id"`
+	Status         string               `json:"status"`
+	ProgressDetail dockerProgressDetail `json:"progressDetail"`
+	Error          string               `json:"error"`
+}
+
+type dockerProgressDetail struct {
+	Current int64 `json:"current"`
+	Total   int64 `json:"total"`
+}
+
+type dockerContainerSummary struct {
+	Id     string               `json:"Id"`
+	Names  []string             `json:"Names"`
+	State  string               `json:"State"`
+	Labels map[string]string    `json:"Labels"`
+	Mounts []dockerMountSummary `json:"Mounts"`
+}
+
+type dockerMountSummary struct {
+	Type        string `json:"Type"`
+	Name        string `json:"Name"`
+	Destination string `json:"Destination"`
+}
+
+type dockerHealth struct {
+	Status string `json:"Status"`
+}
+
+type dockerHealthcheck struct {
+	Test        []string `json:"Test"`
+	Interval    int64    `json:"Interval"`
+	Timeout     int64    `json:"Timeout"`
+	StartPeriod int64    `json:"StartPeriod"`
+	Retries     int      `json:"Retries"`
+}
+
+type dockerContainerState struct {
+	Health    *dockerHealth `json:"Health"`
+	Status    string        `json:"Status"`
+	Running   bool          `json:"Running"`
+	ExitCode  int           `json:"ExitCode"`
+	StartedAt string        `json:"StartedAt"`
+}
+
+type dockerInspectHostConfig struct {
+	PortBindings map[string][]dockerPortBinding `json:"PortBindings"`
+}
+
+type dockerInspectConfig struct {
+	// image reference the container was created from
+	Image string `json:"Image"`
+	Tty   bool   `json:"Tty"`
+}
+
+type dockerContainerInspect struct {
+	Id           string                  `json:"Id"`
+	Image        string                  `json:"Image"`
+	RestartCount int                     `json:"RestartCount"`
+	State        dockerContainerState    `json:"State"`
+	Config       dockerInspectConfig     `json:"Config"`
+	HostConfig   dockerInspectHostConfig `json:"HostConfig"`
+
+	NetworkSettings struct {
+		Networks map[string]json.RawMessage `json:"Networks"`
+	} `json:"NetworkSettings"`
+}
+
+type dockerImageInspect struct {
+	Id          string   `json:"Id"`
+	RepoDigests []string `json:"RepoDigests"`
+	Size        int64    `json:"Size"`
+}
+
+type dockerVolumeSummary struct {
+	Name   string            `json:"Name"`
+	Labels map[string]string `json:"Labels"`
+}
+
+type dockerVolumeList struct {
+	Volumes []dockerVolumeSummary `json:"Volumes"`
+}
+
+type dockerNetworkSummary struct {
+	Name string `json:"Name"`
+}
+
+type dockerNetworkInspect struct {
+	IPAM struct {
+		Config []struct {
+			Subnet string `json:"Subnet"`
+		} `json:"Config"`
+	} `json:"IPAM"`
+}
+
+type dockerRestartPolicy struct {
+	Name              string `json:"Name"`
+	MaximumRetryCount uint   `json:"MaximumRetryCount,omitempty"`
+}
+
+type dockerPortBinding struct {
+	HostPort string `json:"HostPort"`
+}
+
+type dockerHostConfig struct {
+	PortBindings  map[string][]dockerPortBinding `json:"PortBindings,omitempty"`
+	Binds         []string                       `json:"Binds,omitempty"`
+	Tmpfs         map[string]string              `json:"Tmpfs,omitempty"`
+	RestartPolicy dockerRestartPolicy            `json:"RestartPolicy"`
+	Memory        int64                          `json:"Memory,omitempty"`
+	MemorySwap    int64                          `json:"MemorySwap,omitempty"`
+	NanoCpus      int64                          `json:"NanoCpus,omitempty"`
+	CpuShares     int64                          `json:"CpuShares,omitempty"`
+	PidsLimit     *int64                         `json:"PidsLimit,omitempty"`
+	GroupAdd      []string                       `json:"GroupAdd,omitempty"`
+	UsernsMode    string                         `json:"UsernsMode,omitempty"`
+}
+
+type dockerCreateVolume struct {
+	Name   string            `json:"Name"`
+	Labels map[string]string `json:"Labels,omitempty"`
+}
+
+type dockerEndpointSettings struct{}
+
+type dockerNetworkingConfig struct {
+	EndpointsConfig map[string]dockerEndpointSettings `json:"EndpointsConfig,omitempty"`
+}
+
+type dockerCreateContainer struct {
+	Image            string                 `json:"Image"`
+	Hostname         string                 `json:"Hostname,omitempty"`
+	User             string                 `json:"User,omitempty"`
+	StopSignal       string                 `json:"StopSignal,omitempty"`
+	StopTimeout      *int                   `json:"StopTimeout,omitempty"`
+	Env              []string               `json:"Env,omitempty"`
+	Labels           map[string]string      `json:"Labels,omitempty"`
+	ExposedPorts     map[string]struct{}    `json:"ExposedPorts,omitempty"`
+	Healthcheck      *dockerHealthcheck     `json:"Healthcheck,omitempty"`
+	HostConfig       dockerHostConfig       `json:"HostConfig"`
+	NetworkingConfig dockerNetworkingConfig `json:"NetworkingConfig"`
+}
+
+type dockerExecCreate struct {
+	AttachStdin  bool     `json:"AttachStdin"`
+	AttachStdout bool     `json:"AttachStdout"`
+	AttachStderr bool     `json:"AttachStderr"`
+	Tty          bool     `json:"Tty"`
+	Cmd          []string `json:"Cmd"`
+}
+
+type dockerExecStart struct {
+	Detach bool `json:"Detach"`
+	Tty    bool `json:"Tty"`
+}
+
+type dockerIdResponse struct {
+	Id string `json:"Id"`
+}
+
+type dockerEvent struct {
+	Action string `json:"Action"`
+	Actor  struct {
+		Attributes map[string]string `json:"Attributes"`
+	} `json:"Actor"`
+	TimeNano int64 `json:"timeNano"`
+}
+
+type dockerCPUStats struct {
+	CPUUsage struct {
+		TotalUsage uint64 `json:"total_usage"`
+	} `json:"cpu_usage"`
+	SystemUsage uint64 `json:"system_cpu_usage"`
+	OnlineCPUs  uint64 `json:"online_cpus"`
+}
+
+type dockerStats struct {
+	Read        time.Time      `json:"read"`
+	CPUStats    dockerCPUStats `json:"cpu_stats"`
+	PreCPUStats dockerCPUStats `json:"precpu_stats"`
+	MemoryStats struct {
+		Usage uint64            `json:"usage"`
+		Limit uint64            `json:"limit"`
+		Stats map[string]uint64 `json:"stats"`
+	} `json:"memory_stats"`
+	Networks map[string]struct {
+		RxBytes uint64 `json:"rx_bytes"`
+		TxBytes uint64 `json:"tx_bytes"`
+	} `json:"networks"`
+	BlkioStats struct {
+		IoServiceBytesRecursive []struct {
+			Op    string `json:"op"`
+			Value uint64 `json:"value"`
+		} `json:"io_service_bytes_recursive"`
+	} `json:"blkio_stats"`
+	PidsStats struct {
+		Current uint64 `json:"current"`
+	} `json:"pids_stats"`
+}
+
+type dockerExecInspect struct {
+	ExitCode int `json:"ExitCode"`
+}
+
+type dockerCreateNetwork struct {
+	Name     string `json:"Name"`
+	Internal bool   `json:"Internal"`
+}
+
+// Docker host is picked from DOCKER_HOST, same as the docker CLI does
+func defaultDockerHostURI() string {
+	if host := os.Getenv("DOCKER_HOST"); host != "" {
+		return host
+	}
+	return defaultDockerHost
+}
+
+func connectDocker(options ConnectOptions) (DockerContext, error) {
+	hostURI := defaultDockerHostURI()
+	slog.Debug("Docker host found", "uri", hostURI)
+
+	u, err := url.Parse(hostURI)
+	if err != nil {
+		return DockerContext{}, fmt.Errorf("parse docker host %q: %w", hostURI, err)
+	}
+
+	network, address := "", ""
+	switch u.Scheme {
+	case "unix":
+		network, address = "unix", u.Path
+	case "tcp", "http":
+		network, address = "tcp", u.Host
+	default:
+		return DockerContext{}, fmt.Errorf("unsupported docker host scheme %q", u.Scheme)
+	}
+
+	dial := func(ctx context.Context) (net.Conn, error) {
+		var dialer net.Dialer
+		return dialer.DialContext(ctx, network, address)
+	}
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return dial(ctx)
+		},
+	}
+
+	conn := DockerContext{
+		client:   &http.Client{Transport: transport},
+		base:     dockerAPIBase,
+		dial:     dial,
+		timeouts: options.Timeouts,
+	}
+
+	_, err = conn.do(context.Background(), http.MethodGet, "/_ping", nil, nil)
+	if err != nil {
+		slog.Error("Couldn't connect to Docker", "error", err)
+		slog.Error("Check if Docker engine is running on the machine")
+		return DockerContext{}, err
+	}
+
+	slog.Debug("Connected to docker")
+	return conn, nil
+}
+
+// request sends a request to the docker engine and returns the raw response.
+// Caller is responsible for closing the body
+func (conn DockerContext) request(
+	ctx context.Context,
+	method string,
+	path string,
+	query url.Values,
+	body any,
+) (resp *http.Response, err error) {
+	// readers are sent as they are, archives being the only raw bodies
+	var reader io.Reader
+	contentType := "application/json"
+	switch body := body.(type) {
+	case nil:
+	case io.Reader:
+		reader = body
+		contentType = "application/x-tar"
+	default:
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	endpoint := conn.base + path
+	if len(query) > 0 {
+		endpoint = endpoint + "?" + query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, reader)
+	if err != nil {
+		return nil, err
+	}
+	if reader != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	return conn.client.Do(req)
+}
+
+// do sends a request and fails on any non 2xx/304 status code
+func (conn DockerContext) do(
+	ctx context.Context,
+	method string,
+	path string,
+	query url.Values,
+	body any,
+) (data []byte, err error) {
+	resp, err := conn.request(ctx, method, path, query, body)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err = io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusNotModified {
+		return data, nil
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return data, dockerError(method, path, resp.StatusCode, data)
+	}
+
+	return data, nil
+}
+
+// exists checks existence of an object by issuing GET to the inspect endpoint
+func (conn DockerContext) exists(
+	ctx context.Context,
+	path string,
+) (exists bool, err error) {
+	resp, err := conn.request(ctx, http.MethodGet, path, nil, nil)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	}
+
+	data, _ := io.ReadAll(resp.Body)
+	return false, dockerError(http.MethodGet, path, resp.StatusCode, data)
+}
+
+// Objects missing for a 404 of the engine, by path prefix. Creating a
+// container only fails that way when its image is missing
+var dockerNotFoundErrs = []struct {
+	prefix string
+	err    error
+}{
+	{"/containers/create", ImageDoesntExistErr},
+	{"/containers/", ContainerDoesntExistErr},
+	{"/images/", ImageDoesntExistErr},
+	{"/volumes/", VolumeDoesntExistErr},
+}
+
+// Error of a failed request. Missing objects are reported with the same
+// errors as other backends report them
+func dockerError(method, path string, status int, body []byte) error {
+	message := fmt.Sprintf("status %d", status)
+	var apiErr dockerAPIError
+	if err := json.Unmarshal(body, &apiErr); err == nil && apiErr.Message != "" {
+		message = apiErr.Message
+	}
+	err := fmt.Errorf("docker %s %s: %s", method, path, message)
+
+	if status != http.StatusNotFound {
+		return err
+	}
+	for _, notFound := range dockerNotFoundErrs {
+		if strings.HasPrefix(path, notFound.prefix) {
+			return fmt.Errorf("%w: %w", notFound.err, err)
+		}
+	}
+	return err
+}
+
+func (conn DockerContext) PullImage(
+	ctx context.Context,
+	imageName string,
+	options PullOptions,
+) error {
+	ctx, cancel := withTimeout(ctx, conn.timeouts.Pull)
+	defer cancel()
+
+	query := url.Values{}
+	query.Set("fromImage", imageName)
+
+	resp, err := conn.request(ctx, http.MethodPost, "/images/create", query, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		data, _ := io.ReadAll(resp.Body)
+		return dockerError(http.MethodPost, "/images/create", resp.StatusCode, data)
+	}
+
+	// pull progress is streamed as json messages, errors in between the
+	// stream are only reported as a message
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var message dockerStreamMessage
+		if err := decoder.Decode(&message); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return err
+		}
+		if message.Error != "" {
+			return fmt.Errorf("pull %s: %s", imageName, message.Error)
+		}
+		options.report(PullProgress{
+			Layer:   message.Id,
+			Status:  message.Status,
+			Current: message.ProgressDetail.Current,
+			Total:   message.ProgressDetail.Total,
+		})
+	}
+
+	if options.Progress != nil {
+		var size int64
+		if imageData, err := conn.inspectImage(ctx, imageName); err == nil {
+			size = imageData.Size
+		}
+		options.report(PullProgress{Done: true, Total: size})
+	}
+
+	slog.Debug("Pulled Docker image", "name", imageName)
+	return nil
+}
+
+func (conn DockerContext) RemoveImage(ctx context.Context, imageName string) error {
+	data, err := conn.do(
+		ctx, http.MethodDelete, "/images/"+url.PathEscape(imageName), nil, nil,
+	)
+	if err != nil {
+		return err
+	}
+	slog.Debug("Image removal report", "report", string(data))
+	return nil
+}
+
+func (conn DockerContext) inspectImage(ctx context.Context, imageName string) (
+	imageData dockerImageInspect, err error,
+) {
+	data, err := conn.do(
+		ctx, http.MethodGet, "/images/"+url.PathEscape(imageName)+"/json", nil, nil,
+	)
+	if err != nil {
+		return
+	}
+	err = json.Unmarshal(data, &imageData)
+	return
+}
+
+func (conn DockerContext) InspectImage(ctx context.Context, imageName string) (
+	details ImageDetails, err error,
+) {
+	exists, err := conn.ImageExists(ctx, imageName)
+	if err != nil {
+		return
+	}
+	if !exists {
+		return details, ImageDoesntExistErr
+	}
+
+	imageData, err := conn.inspectImage(ctx, imageName)
+	if err != nil {
+		return
+	}
+	return ImageDetails{
+		ID:     imageData.Id,
+		Digest: pulledDigest(imageName, imageData.RepoDigests, ""),
+		Size:   imageData.Size,
+	}, nil
+}
+
+func (conn DockerContext) ImageExists(
+	ctx context.Context,
+	imageName string,
+) (exists bool, err error) {
+	return conn.exists(ctx, "/images/"+url.PathEscape(imageName)+"/json")
+}
+
+func (conn DockerContext) ListContainers(ctx context.Context) (
+	containerNames []string, err error,
+) {
+	data, err := conn.do(ctx, http.MethodGet, "/containers/json", nil, nil)
+	if err != nil {
+		return
+	}
+
+	var containerList []dockerContainerSummary
+	if err = json.Unmarshal(data, &containerList); err != nil {
+		return
+	}
+
+	for _, cont := range containerList {
+		for _, name := range cont.Names {
+			containerNames = append(containerNames, strings.TrimPrefix(name, "/"))
+		}
+	}
+
+	return
+}
+
+// Lists containers created by rocket, running or not
+func (conn DockerContext) ListManagedContainers(ctx context.Context) (
+	managed []ManagedContainer, err error,
+) {
+	filters, err := json.Marshal(map[string][]string{"label": {managedFilter}})
+	if err != nil {
+		return
+	}
+
+	query := url.Values{}
+	query.Set("all", "true")
+	query.Set("filters", string(filters))
+
+	data, err := conn.do(ctx, http.MethodGet, "/containers/json", query, nil)
+	if err != nil {
+		return
+	}
+
+	var containerList []dockerContainerSummary
+	if err = json.Unmarshal(data, &containerList); err != nil {
+		return
+	}
+
+	for _, cont := range containerList {
+		for _, name := range cont.Names {
+			managed = append(managed, ManagedContainer{
+				Name:   strings.TrimPrefix(name, "/"),
+				State:  cont.State,
+				Labels: cont.Labels,
+			})
+		}
+	}
+
+	return
+}
+
+func (conn DockerContext) ContainerExists(
+	ctx context.Context,
+	containerName string,
+) (exists bool, err error) {
+	return conn.exists(ctx, "/containers/"+url.PathEscape(containerName)+"/json")
+}
+
+func (conn DockerContext) inspect(ctx context.Context, containerName string) (
+	ctrData dockerContainerInspect, err error,
+) {
+	data, err := conn.do(
+		ctx,
+		http.MethodGet,
+		"/containers/"+url.PathEscape(containerName)+"/json",
+		nil,
+		nil,
+	)
+	if err != nil {
+		return
+	}
+	err = json.Unmarshal(data, &ctrData)
+	return
+}
+
+// Digest the image of the container was pulled by, the same digest images
+// are pinned to. Image is the local image ID, which is only reported for
+// images that were never pulled from a registry
+func (conn DockerContext) containerImageDigest(
+	ctx context.Context,
+	ctrData dockerContainerInspect,
+) string {
+	imageData, err := conn.inspectImage(ctx, ctrData.Image)
+	if err != nil {
+		slog.Debug("Image of container not inspected", "image", ctrData.Image, "error", err)
+		return ctrData.Image
+	}
+	return cmp.Or(pulledDigest(ctrData.Config.Image, imageData.RepoDigests, ""), ctrData.Image)
+}
+
+func (conn DockerContext) InspectContainer(
+	ctx context.Context,
+	containerName string,
+) (status ContainerStatus, err error) {
+	exists, err := conn.ContainerExists(ctx, containerName)
+	if err != nil {
+		return
+	}
+	if !exists {
+		return status, ContainerDoesntExistErr
+	}
+
+	ctrData, err := conn.inspect(ctx, containerName)
+	if err != nil {
+		return
+	}
+
+	status = ContainerStatus{
+		Name:         containerName,
+		State:        ctrData.State.Status,
+		ExitCode:     ctrData.State.ExitCode,
+		RestartCount: ctrData.RestartCount,
+		ImageDigest:  conn.containerImageDigest(ctx, ctrData),
+		Ports:        map[int]int{},
+	}
+	if ctrData.State.Health != nil {
+		status.Health = ctrData.State.Health.Status
+	}
+
+	// docker reports zero time for containers that never started
+	startedAt, err := time.Parse(time.RFC3339Nano, ctrData.State.StartedAt)
+	if err == nil && startedAt.Year() > 1 {
+		status.StartedAt = startedAt
+	}
+
+	if len(ctrData.NetworkSettings.Networks) > 0 {
+		status.Networks = slices.Sorted(maps.Keys(ctrData.NetworkSettings.Networks))
+	}
+
+	for containerPort, bindings := range ctrData.HostConfig.PortBindings {
+		ctrPort, err := parsePortKey(containerPort)
+		if err != nil {
+			continue
+		}
+		for _, binding := range bindings {
+			hostPort, err := strconv.Atoi(binding.HostPort)
+			if err != nil {
+				continue
+			}
+			status.Ports[hostPort] = ctrPort
+		}
+	}
+
+	return status, nil
+}
+
+// Streams start, stop, die and remove events of managed containers to
+// handle until ctx is done
+func (conn DockerContext) Events(
+	ctx context.Context,
+	handle func(Event),
+) (err error) {
+	filters, err := json.Marshal(map[string][]string{
+		"type":  {"container"},
+		"label": {managedFilter},
+	})
+	if err != nil {
+		return err
+	}
+	query := url.Values{}
+	query.Set("filters", string(filters))
+
+	resp, err := conn.request(ctx, http.MethodGet, "/events", query, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		data, _ := io.ReadAll(resp.Body)
+		return dockerError(http.MethodGet, "/events", resp.StatusCode, data)
+	}
+
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var raw dockerEvent
+		if err = decoder.Decode(&raw); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+		event, ok := newEvent(raw.Action, raw.Actor.Attributes, time.Unix(0, raw.TimeNano))
+		if ok {
+			handle(event)
+		}
+	}
+}
+
+// Resource usage of the running containers, computed the way `docker stats`
+// does
+func (conn DockerContext) Stats(
+	ctx context.Context,
+	containerNames []string,
+) (stats []ContainerStats, err error) {
+	// docker samples every container for a second, containers are sampled
+	// at once rather than one after the other
+	stats = make([]ContainerStats, len(containerNames))
+	errs := make([]error, len(containerNames))
+	var wg sync.WaitGroup
+	for index, containerName := range containerNames {
+		wg.Go(func() {
+			stats[index], errs[index] = conn.containerStats(ctx, containerName)
+		})
+	}
+	wg.Wait()
+
+	if err = errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+func (conn DockerContext) containerStats(
+	ctx context.Context,
+	containerName string,
+) (sample ContainerStats, err error) {
+	query := url.Values{}
+	query.Set("stream", "false")
+
+	path := "/containers/" + url.PathEscape(containerName) + "/stats"
+	data, err := conn.do(ctx, http.MethodGet, path, query, nil)
+	if err != nil {
+		return sample, err
+	}
+
+	var raw dockerStats
+	if err = json.Unmarshal(data, &raw); err != nil {
+		return sample, err
+	}
+
+	sample = ContainerStats{
+		Name:        containerName,
+		CPUTime:     time.Duration(raw.CPUStats.CPUUsage.TotalUsage),
+		MemoryUsage: raw.MemoryStats.Usage,
+		MemoryLimit: raw.MemoryStats.Limit,
+		PIDs:        raw.PidsStats.Current,
+		Read:        raw.Read,
+	}
+
+	cpuDelta := float64(raw.CPUStats.CPUUsage.TotalUsage) -
+		float64(raw.PreCPUStats.CPUUsage.TotalUsage)
+	systemDelta := float64(raw.CPUStats.SystemUsage) -
+		float64(raw.PreCPUStats.SystemUsage)
+	if cpuDelta > 0 && systemDelta > 0 {
+		sample.CPUPercent = cpuDelta / systemDelta *
+			float64(raw.CPUStats.OnlineCPUs) * 100
+	}
+
+	// page cache is reclaimable, cgroup v2 reports it as inactive_file
+	// and v1 as cache
+	for _, key := range []string{"inactive_file", "cache"} {
+		cache, ok := raw.MemoryStats.Stats[key]
+		if ok && cache < sample.MemoryUsage {
+			sample.MemoryUsage -= cache
+			break
+		}
+	}
+
+	for _, network := range raw.Networks {
+		sample.NetInput += network.RxBytes
+		sample.NetOutput += network.TxBytes
+	}
+	for _, entry := range raw.BlkioStats.IoServiceBytesRecursive {
+		switch strings.ToLower(entry.Op) {
+		case "read":
+			sample.BlockInput += entry.Value
+		case "write":
+			sample.BlockOutput += entry.Value
+		}
+	}
+
+	return sample, nil
+}
+
+// Writes logs of the container to stdout and stderr. Blocks till the logs
+// are exhausted, or till the container exits when following
+func (conn DockerContext) Logs(
+	ctx context.Context,
+	containerName string,
+	options LogOptions,
+	stdout io.Writer,
+	stderr io.Writer,
+) (err error) {
+	ctrData, err := conn.inspect(ctx, containerName)
+	if err != nil {
+		return
+	}
+
+	query := url.Values{}
+	query.Set("stdout", "true")
+	query.Set("stderr", "true")
+	query.Set("follow", strconv.FormatBool(options.Follow))
+	query.Set("tail", options.tail())
+	if since := options.since(); since != "" {
+		query.Set("since", since)
+	}
+
+	path := "/containers/" + url.PathEscape(containerName) + "/logs"
+	resp, err := conn.request(ctx, http.MethodGet, path, query, nil)
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		data, _ := io.ReadAll(resp.Body)
+		return dockerError(http.MethodGet, path, resp.StatusCode, data)
+	}
+
+	// tty containers stream raw output, others multiplex both streams
+	if ctrData.Config.Tty {
+		_, err = io.Copy(stdout, resp.Body)
+		return
+	}
+	return dockerDemux(resp.Body, stdout, stderr)
+}
+
+// Splits docker multiplexed stream into stdout and stderr. Every frame has a
+// 8 byte header: stream type, 3 bytes padding, big endian payload size
+func dockerDemux(reader io.Reader, stdout, stderr io.Writer) error {
+	header := make([]byte, 8)
+	for {
+		if _, err := io.ReadFull(reader, header); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+
+		size := int64(binary.BigEndian.Uint32(header[4:]))
+		writer := stdout
+		if header[0] == 2 {
+			writer = stderr
+		}
+
+		if _, err := io.CopyN(writer, reader, size); err != nil {
+			return err
+		}
+	}
+}
+
+// Runs command in the container and returns exit code of the command
+func (conn DockerContext) Exec(
+	ctx context.Context,
+	containerName string,
+	options ExecOptions,
+) (exitCode int, err error) {
+	data, err := conn.do(
+		ctx,
+		http.MethodPost,
+		"/containers/"+url.PathEscape(containerName)+"/exec",
+		nil,
+		dockerExecCreate{
+			AttachStdin:  options.Interactive,
+			AttachStdout: true,
+			AttachStderr: true,
+			Tty:          options.Tty,
+			Cmd:          options.Cmd,
+		},
+	)
+	if err != nil {
+		return -1, fmt.Errorf("create exec session in %q: %w", containerName, err)
+	}
+
+	var session dockerIdResponse
+	if err = json.Unmarshal(data, &session); err != nil {
+		return -1, err
+	}
+
+	if err = conn.execStartAndAttach(ctx, session.Id, options); err != nil {
+		return -1, err
+	}
+
+	data, err = conn.do(ctx, http.MethodGet, "/exec/"+session.Id+"/json", nil, nil)
+	if err != nil {
+		return -1, err
+	}
+	var inspect dockerExecInspect
+	if err = json.Unmarshal(data, &inspect); err != nil {
+		return -1, err
+	}
+
+	slog.Debug("Exec session finished", "session", session.Id, "exit_code", inspect.ExitCode)
+	return inspect.ExitCode, nil
+}
+
+// Starts exec session over a hijacked connection, the engine upgrades the
+// http connection to a raw stream of the process' stdio
+func (conn DockerContext) execStartAndAttach(
+	ctx context.Context,
+	sessionID string,
+	options ExecOptions,
+) (err error) {
+	rawConn, err := conn.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer rawConn.Close()
+
+	// hijacked connection is outside of the http client, close it ourselves
+	// once the context is done
+	stop := context.AfterFunc(ctx, func() { _ = rawConn.Close() })
+	defer stop()
+
+	body, err := json.Marshal(dockerExecStart{Tty: options.Tty})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(
+		ctx,
+		http.MethodPost,
+		dockerAPIBase+"/exec/"+sessionID+"/start",
+		bytes.NewReader(body),
+	)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "tcp")
+
+	if err = req.Write(rawConn); err != nil {
+		return err
+	}
+
+	reader := bufio.NewReader(rawConn)
+	resp, err := http.ReadResponse(reader, req)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols &&
+		resp.StatusCode != http.StatusOK {
+		data, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		return dockerError(http.MethodPost, "/exec/start", resp.StatusCode, data)
+	}
+
+	if options.Interactive && options.Stdin != nil {
+		go func() {
+			_, _ = io.Copy(rawConn, options.Stdin)
+			if closer, ok := rawConn.(interface{ CloseWrite() error }); ok {
+				_ = closer.CloseWrite()
+			}
+		}()
+	}
+
+	if options.Tty {
+		_, err = io.Copy(options.Stdout, reader)
+		return err
+	}
+	return dockerDemux(reader, options.Stdout, options.Stderr)
+}
+
+func (conn DockerContext) CreateContainer(
+	ctx context.Context,
+	options Config,
+) (err error) {
+	ctx, cancel := withTimeout(ctx, conn.timeouts.Create)
+	defer cancel()
+
+	image := options.Image()
+
+	s := dockerCreateContainer{
+		Image:    image,
+		Hostname: options.ContainerName,
+		Labels:   containerLabels(options),
+	}
+	if err = dockerLifecycle(&s, options.Lifecycle); err != nil {
+		return err
+	}
+
+	for key, value := range options.Environment() {
+		s.Env = append(s.Env, key+"="+value)
+	}
+	slices.Sort(s.Env)
+
+	if err = options.Egress.validate(); err != nil {
+		return err
+	}
+	for _, network := range options.Networks() {
+		if s.NetworkingConfig.EndpointsConfig == nil {
+			s.NetworkingConfig.EndpointsConfig = map[string]dockerEndpointSettings{}
+		}
+		s.NetworkingConfig.EndpointsConfig[network] = dockerEndpointSettings{}
+	}
+
+	for hostPort, containerPort := range options.BindPorts {
+		if s.ExposedPorts == nil {
+			s.ExposedPorts = map[string]struct{}{}
+			s.HostConfig.PortBindings = map[string][]dockerPortBinding{}
+		}
+		port := strconv.Itoa(containerPort) + "/tcp"
+		s.ExposedPorts[port] = struct{}{}
+		s.HostConfig.PortBindings[port] = append(
+			s.HostConfig.PortBindings[port],
+			dockerPortBinding{HostPort: strconv.Itoa(hostPort)},
+		)
+	}
+
+	// binds and volumes share the "source:destination:options" syntax
+	for _, mount := range options.MountDirs {
+		if err = mount.validate(); err != nil {
+			return err
+		}
+
+		if len(mount.ownershipOptions()) > 0 {
+			return fmt.Errorf(
+				"%w: ownership options on mount %q",
+				IdentityUnsupportedErr, mount.Destination,
+			)
+		}
+
+		mountOptions := strings.Join(mount.options(), ",")
+		switch mount.kind() {
+		case MountBind, MountVolume:
+			s.HostConfig.Binds = append(
+				s.HostConfig.Binds,
+				mount.Source+":"+mount.Destination+":"+mountOptions,
+			)
+		case MountTmpfs:
+			if s.HostConfig.Tmpfs == nil {
+				s.HostConfig.Tmpfs = map[string]string{}
+			}
+			s.HostConfig.Tmpfs[mount.Destination] = mountOptions
+		}
+	}
+
+	if err = dockerIdentity(&s, options.Identity); err != nil {
+		return err
+	}
+
+	limits, err := options.Resources.linuxResources()
+	if err != nil {
+		return err
+	}
+	if limits != nil {
+		if limits.Memory != nil && limits.Memory.Limit != nil {
+			s.HostConfig.Memory = *limits.Memory.Limit
+		}
+		if limits.Memory != nil && limits.Memory.Swap != nil {
+			s.HostConfig.MemorySwap = *limits.Memory.Swap
+		}
+		if limits.CPU != nil && limits.CPU.Shares != nil {
+			s.HostConfig.CpuShares = int64(*limits.CPU.Shares)
+		}
+		if limits.Pids != nil {
+			s.HostConfig.PidsLimit = limits.Pids.Limit
+		}
+	}
+	s.HostConfig.NanoCpus = int64(options.Resources.CPUs * 1e9)
+
+	health, err := options.healthConfig()
+	if err != nil {
+		return err
+	}
+	if health != nil {
+		s.Healthcheck = &dockerHealthcheck{
+			Test:        health.Test,
+			Interval:    int64(health.Interval),
+			Timeout:     int64(health.Timeout),
+			StartPeriod: int64(health.StartPeriod),
+			Retries:     health.Retries,
+		}
+	}
+
+	containerExists, err := conn.ContainerExists(ctx, options.ContainerName)
+	if err != nil {
+		slog.Debug("Failed to check if container already exists", "error", err)
+		return err
+	}
+	if containerExists {
+		slog.Debug("Container already exists")
+		return nil
+	}
+
+	if err = conn.ensureVolumes(ctx, options); err != nil {
+		return err
+	}
+
+	query := url.Values{}
+	query.Set("name", options.ContainerName)
+
+	ctr, err := conn.do(ctx, http.MethodPost, "/containers/create", query, s)
+	if err != nil {
+		return fmt.Errorf("create container %q failed: %w", options.ContainerName, err)
+	}
+	slog.Debug("Container created", "response", string(ctr))
+	return nil
+}
+
+func dockerLifecycle(s *dockerCreateContainer, lifecycle Lifecycle) error {
+	if err := lifecycle.validate(); err != nil {
+		return err
+	}
+
+	s.HostConfig.RestartPolicy = dockerRestartPolicy{
+		Name:              lifecycle.restartPolicy(),
+		MaximumRetryCount: lifecycle.MaxRetries,
+	}
+	s.StopSignal = lifecycle.StopSignal
+
+	stopSeconds, _ := lifecycle.stopSeconds()
+	if stopSeconds > 0 {
+		timeout := int(stopSeconds)
+		s.StopTimeout = &timeout
+	}
+	return nil
+}
+
+// Sets user and groups of the container. Docker only shares the user
+// namespace of the host, remapping ids is configured on the daemon
+func dockerIdentity(s *dockerCreateContainer, identity Identity) error {
+	if err := identity.validate(); err != nil {
+		return err
+	}
+
+	mode := identity.usernsMode()
+	if identity.hasIDMaps() || (mode != "" && mode != UsernsHost) {
+		return fmt.Errorf(
+			"%w: user namespace %q", IdentityUnsupportedErr, identity.UserNS,
+		)
+	}
+
+	s.User = identity.userSpec()
+	s.HostConfig.GroupAdd = identity.GroupAdd
+	s.HostConfig.UsernsMode = mode
+	return nil
+}
+
+// Creates named volumes of the container that do not exist yet, labelled as
+// owned by the application
+func (conn DockerContext) ensureVolumes(
+	ctx context.Context,
+	options Config,
+) (err error) {
+	for _, mount := range options.MountDirs {
+		if mount.kind() != MountVolume {
+			continue
+		}
+
+		exists, err := conn.exists(ctx, "/volumes/"+url.PathEscape(mount.Source))
+		if err != nil {
+			return err
+		}
+		if exists {
+			continue
+		}
+
+		_, err = conn.do(ctx, http.MethodPost, "/volumes/create", nil, dockerCreateVolume{
+			Name:   mount.Source,
+			Labels: ownerLabels(options),
+		})
+		if err != nil {
+			return fmt.Errorf("create volume %q failed: %w", mount.Source, err)
+		}
+		slog.Debug("Created volume", "name", mount.Source)
+	}
+	return nil
+}
+
+func (conn DockerContext) RemoveContainer(
+	ctx context.Context,
+	containerName string,
+	force bool,
+) (err error) {
+	query := url.Values{}
+	query.Set("force", strconv.FormatBool(force))
+	_, err = conn.do(
+		ctx,
+		http.MethodDelete, "/containers/"+url.PathEscape(containerName), query, nil,
+	)
+	return
+}
+
+func (conn DockerContext) StartService(
+	ctx context.Context,
+	containerName string,
+) (err error) {
+	_, err = conn.do(
+		ctx,
+		http.MethodPost,
+		"/containers/"+url.PathEscape(containerName)+"/start",
+		nil,
+		nil,
+	)
+	return
+}
+
+func (conn DockerContext) StopService(
+	ctx context.Context,
+	containerName string,
+) (err error) {
+	query := url.Values{}
+	if seconds, ok := conn.timeouts.stopSeconds(); ok {
+		query.Set("t", strconv.FormatUint(uint64(seconds), 10))
+	}
+
+	_, err = conn.do(
+		ctx,
+		http.MethodPost,
+		"/containers/"+url.PathEscape(containerName)+"/stop",
+		query,
+		nil,
+	)
+	return
+}
+
+func (conn DockerContext) PauseService(
+	ctx context.Context,
+	containerName string,
+) (err error) {
+	_, err = conn.do(
+		ctx,
+		http.MethodPost,
+		"/containers/"+url.PathEscape(containerName)+"/pause",
+		nil,
+		nil,
+	)
+	return
+}
+
+func (conn DockerContext) UnpauseService(
+	ctx context.Context,
+	containerName string,
+) (err error) {
+	_, err = conn.do(
+		ctx,
+		http.MethodPost,
+		"/containers/"+url.PathEscape(containerName)+"/unpause",
+		nil,
+		nil,
+	)
+	return
+}
+
+// Pulls newest image for the container and recreates the container when the
+// pulled image differs from the one the container runs
+func (conn DockerContext) UpdateService(ctx context.Context, options Config) (
+	updated bool, err error,
+) {
+	exists, err := conn.ContainerExists(ctx, options.ContainerName)
+	if err != nil {
+		return false, err
+	}
+	if !exists {
+		return false, ContainerDoesntExistErr
+	}
+
+	ctrData, err := conn.inspect(ctx, options.ContainerName)
+	if err != nil {
+		return false, err
+	}
+
+	image := options.Image()
+	if err = conn.PullImage(ctx, image, PullOptions{}); err != nil {
+		return false, err
+	}
+
+	imageData, err := conn.inspectImage(ctx, image)
+	if err != nil {
+		return false, err
+	}
+
+	slog.Debug(
+		"Compared container image",
+		"container", options.ContainerName,
+		"current", ctrData.Image,
+		"pulled", imageData.Id,
+	)
+	if imageData.Id == ctrData.Image {
+		return false, nil
+	}
+
+	if err = recreateService(ctx, conn, options, ctrData.State.Running); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (conn DockerContext) CreatePod(
+	ctx context.Context,
+	options Config,
+) (err error) {
+	return PodsUnsupportedErr
+}
+
+func (conn DockerContext) StartPod(
+	ctx context.Context,
+	podName string,
+) (err error) {
+	return PodsUnsupportedErr
+}
+
+func (conn DockerContext) StopPod(ctx context.Context, podName string) (err error) {
+	return PodsUnsupportedErr
+}
+
+func (conn DockerContext) RemovePod(
+	ctx context.Context,
+	podName string,
+	force bool,
+) (err error) {
+	return PodsUnsupportedErr
+}
+
+func (conn DockerContext) PodExists(
+	ctx context.Context,
+	podName string,
+) (exists bool, err error) {
+	return false, PodsUnsupportedErr
+}
+
+func (conn DockerContext) ListManagedPods(ctx context.Context) (
+	managed []ManagedPod, err error,
+) {
+	return nil, PodsUnsupportedErr
+}
+
+// Lists volumes created by rocket
+func (conn DockerContext) ListManagedVolumes(ctx context.Context) (
+	managed []ManagedVolume, err error,
+) {
+	filters, err := json.Marshal(map[string][]string{"label": {managedFilter}})
+	if err != nil {
+		return
+	}
+
+	query := url.Values{}
+	query.Set("filters", string(filters))
+
+	data, err := conn.do(ctx, http.MethodGet, "/volumes", query, nil)
+	if err != nil {
+		return
+	}
+
+	var volumeList dockerVolumeList
+	if err = json.Unmarshal(data, &volumeList); err != nil {
+		return
+	}
+
+	for _, volume := range volumeList.Volumes {
+		managed = append(managed, ManagedVolume{
+			Name:   volume.Name,
+			Labels: volume.Labels,
+		})
+	}
+
+	return
+}
+
+func (conn DockerContext) RemoveVolume(
+	ctx context.Context,
+	volumeName string,
+	force bool,
+) (err error) {
+	query := url.Values{}
+	query.Set("force", strconv.FormatBool(force))
+	_, err = conn.do(
+		ctx, http.MethodDelete, "/volumes/"+url.PathEscape(volumeName), query, nil,
+	)
+	return
+}
+
+// Docker cannot export volumes. The contents are copied out of a container
+// mounting the volume instead, without the directory it is mounted on
+func (conn DockerContext) ExportVolume(
+	ctx context.Context,
+	volumeName string,
+	contents io.Writer,
+) (err error) {
+	containerID, destination, err := conn.volumeMount(ctx, volumeName)
+	if err != nil {
+		return err
+	}
+
+	query := url.Values{}
+	query.Set("path", destination)
+	resp, err := conn.request(
+		ctx,
+		http.MethodGet,
+		"/containers/"+url.PathEscape(containerID)+"/archive",
+		query,
+		nil,
+	)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		data, _ := io.ReadAll(resp.Body)
+		return dockerError(http.MethodGet, "/containers/archive", resp.StatusCode, data)
+	}
+
+	reader := tar.NewReader(resp.Body)
+	writer := tar.NewWriter(contents)
+	for {
+		header, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		// entries are prefixed with the base name of the mount destination
+		_, name, _ := strings.Cut(header.Name, "/")
+		if name == "" {
+			continue
+		}
+		header.Name = name
+		if err = writer.WriteHeader(header); err != nil {
+			return err
+		}
+		if _, err = io.Copy(writer, reader); err != nil {
+			return err
+		}
+	}
+	return writer.Close()
+}
+
+// Extracts the tar archive into the volume through a container mounting it,
+// keeping files it does not contain
+func (conn DockerContext) ImportVolume(
+	ctx context.Context,
+	volumeName string,
+	contents io.Reader,
+) (err error) {
+	containerID, destination, err := conn.volumeMount(ctx, volumeName)
+	if err != nil {
+		return err
+	}
+
+	query := url.Values{}
+	query.Set("path", destination)
+	_, err = conn.do(
+		ctx,
+		http.MethodPut,
+		"/containers/"+url.PathEscape(containerID)+"/archive",
+		query,
+		contents,
+	)
+	return
+}
+
+// Finds a container mounting the volume and where it is mounted
+func (conn DockerContext) volumeMount(ctx context.Context, volumeName string) (
+	containerID string, destination string, err error,
+) {
+	exists, err := conn.exists(ctx, "/volumes/"+url.PathEscape(volumeName))
+	if err != nil {
+		return "", "", err
+	}
+	if !exists {
+		return "", "", VolumeDoesntExistErr
+	}
+
+	filters, err := json.Marshal(map[string][]string{"volume": {volumeName}})
+	if err != nil {
+		return "", "", err
+	}
+	query := url.Values{}
+	query.Set("all", "true")
+	query.Set("filters", string(filters))
+
+	data, err := conn.do(ctx, http.MethodGet, "/containers/json", query, nil)
+	if err != nil {
+		return "", "", err
+	}
+
+	var containerList []dockerContainerSummary
+	if err = json.Unmarshal(data, &containerList); err != nil {
+		return "", "", err
+	}
+	for _, cont := range containerList {
+		for _, mount := range cont.Mounts {
+			if mount.Type == MountVolume && mount.Name == volumeName {
+				return cont.Id, mount.Destination, nil
+			}
+		}
+	}
+	return "", "", fmt.Errorf("volume %q is not mounted by any container", volumeName)
+}
+
+func (conn DockerContext) ListNetworks(ctx context.Context) (
+	networks []string, err error,
+) {
+	data, err := conn.do(ctx, http.MethodGet, "/networks", nil, nil)
+	if err != nil {
+		return
+	}
+
+	var networkList []dockerNetworkSummary
+	if err = json.Unmarshal(data, &networkList); err != nil {
+		return
+	}
+
+	for _, network := range networkList {
+		networks = append(networks, network.Name)
+	}
+
+	return
+}
+
+func (conn DockerContext) CreateNetwork(
+	ctx context.Context,
+	networkName string,
+	internal bool,
+) (err error) {
+	// user defined docker networks always have the embedded DNS server
+	_, err = conn.do(
+		ctx,
+		http.MethodPost, "/networks/create", nil, dockerCreateNetwork{
+			Name:     networkName,
+			Internal: internal,
+		},
+	)
+	return err
+}
+
+func (conn DockerContext) NetworkExists(ctx context.Context, networkName string) (
+	exists bool, err error,
+) {
+	return conn.exists(ctx, "/networks/"+url.PathEscape(networkName))
+}
+
+// Subnets of the network in CIDR notation e.g. "172.18.0.0/16"
+func (conn DockerContext) NetworkSubnets(
+	ctx context.Context,
+	networkName string,
+) (subnets []string, err error) {
+	data, err := conn.do(
+		ctx, http.MethodGet, "/networks/"+url.PathEscape(networkName), nil, nil,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var networkData dockerNetworkInspect
+	if err = json.Unmarshal(data, &networkData); err != nil {
+		return nil, err
+	}
+	for _, config := range networkData.IPAM.Config {
+		if config.Subnet != "" {
+			subnets = append(subnets, config.Subnet)
+		}
+	}
+	return subnets, nil
+}
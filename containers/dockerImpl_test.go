@@ -0,0 +1,358 @@
+package containers
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// Docker manager talking to an engine served by handler
+func newTestDocker(t *testing.T, handler http.Handler) DockerContext {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	dial := func(ctx context.Context) (net.Conn, error) {
+		var dialer net.Dialer
+		return dialer.DialContext(ctx, "tcp", server.Listener.Addr().String())
+	}
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return dial(ctx)
+		},
+	}
+	t.Cleanup(transport.CloseIdleConnections)
+
+	return DockerContext{
+		client: &http.Client{Transport: transport},
+		base:   dockerAPIBase,
+		dial:   dial,
+	}
+}
+
+// Frame of a multiplexed docker stream
+func dockerFrame(stream byte, payload string) []byte {
+	header := make([]byte, 8)
+	header[0] = stream
+	binary.BigEndian.PutUint32(header[4:], uint32(len(payload)))
+	return append(header, payload...)
+}
+
+func TestDockerDemux(t *testing.T) {
+	var stream bytes.Buffer
+	stream.Write(dockerFrame(1, "out one\n"))
+	stream.Write(dockerFrame(2, "err\n"))
+	stream.Write(dockerFrame(1, ""))
+	stream.Write(dockerFrame(1, "out two\n"))
+
+	var stdout, stderr bytes.Buffer
+	if err := dockerDemux(&stream, &stdout, &stderr); err != nil {
+		t.Fatalf("dockerDemux: %v", err)
+	}
+	if got := stdout.String(); got != "out one\nout two\n" {
+		t.Errorf("stdout = %q", got)
+	}
+	if got := stderr.String(); got != "err\n" {
+		t.Errorf("stderr = %q", got)
+	}
+
+	truncated := dockerFrame(1, "cut short")[:12]
+	err := dockerDemux(bytes.NewReader(truncated), io.Discard, io.Discard)
+	if err == nil {
+		t.Error("dockerDemux accepted a truncated frame")
+	}
+}
+
+func TestDockerStats(t *testing.T) {
+	const stats = `{
+		"read": "2026-01-02T03:04:05Z",
+		"cpu_stats": {
+			"cpu_usage": {"total_usage": 3000},
+			"system_cpu_usage": 20000,
+			"online_cpus": 4
+		},
+		"precpu_stats": {
+			"cpu_usage": {"total_usage": 1000},
+			"system_cpu_usage": 10000
+		},
+		"memory_stats": {
+			"usage": 1000,
+			"limit": 4000,
+			"stats": {"inactive_file": 200}
+		},
+		"networks": {
+			"eth0": {"rx_bytes": 10, "tx_bytes": 20},
+			"eth1": {"rx_bytes": 1, "tx_bytes": 2}
+		},
+		"blkio_stats": {
+			"io_service_bytes_recursive": [
+				{"op": "Read", "value": 7},
+				{"op": "Write", "value": 9},
+				{"op": "read", "value": 1}
+			]
+		},
+		"pids_stats": {"current": 5}
+	}`
+	conn := newTestDocker(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/containers/web/stats" || r.URL.Query().Get("stream") != "false" {
+			http.NotFound(w, r)
+			return
+		}
+		io.WriteString(w, stats)
+	}))
+
+	samples, err := conn.Stats(t.Context(), []string{"web"})
+	if err != nil {
+		t.Fatalf("Stats: %v", err)
+	}
+	if len(samples) != 1 {
+		t.Fatalf("Stats() = %v, want one sample", samples)
+	}
+	sample := samples[0]
+
+	// 2000 of 10000 system ns on 4 cpus
+	if sample.CPUPercent != 80 {
+		t.Errorf("CPUPercent = %v, want 80", sample.CPUPercent)
+	}
+	if sample.MemoryUsage != 800 || sample.MemoryLimit != 4000 {
+		t.Errorf("memory = %d of %d, want 800 of 4000 without page cache",
+			sample.MemoryUsage, sample.MemoryLimit)
+	}
+	if sample.NetInput != 11 || sample.NetOutput != 22 {
+		t.Errorf("network = %d in, %d out, want 11 in, 22 out", sample.NetInput, sample.NetOutput)
+	}
+	if sample.BlockInput != 8 || sample.BlockOutput != 9 {
+		t.Errorf("block io = %d in, %d out, want 8 in, 9 out", sample.BlockInput, sample.BlockOutput)
+	}
+	if sample.PIDs != 5 {
+		t.Errorf("PIDs = %d, want 5", sample.PIDs)
+	}
+}
+
+func TestDockerStatsCgroupV1Cache(t *testing.T) {
+	conn := newTestDocker(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"memory_stats": {"usage": 1000, "stats": {"cache": 300}}}`)
+	}))
+
+	samples, err := conn.Stats(t.Context(), []string{"web"})
+	if err != nil {
+		t.Fatalf("Stats: %v", err)
+	}
+	if samples[0].MemoryUsage != 700 {
+		t.Errorf("MemoryUsage = %d, want 700 without page cache", samples[0].MemoryUsage)
+	}
+	if samples[0].CPUPercent != 0 {
+		t.Errorf("CPUPercent = %v without a previous sample, want 0", samples[0].CPUPercent)
+	}
+}
+
+func TestDockerErrorStatus(t *testing.T) {
+	conn := newTestDocker(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/networks/") {
+			w.WriteHeader(http.StatusInternalServerError)
+			io.WriteString(w, `{"message": "engine broke"}`)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+		io.WriteString(w, `{"message": "no such object"}`)
+	}))
+	ctx := t.Context()
+
+	tests := []struct {
+		name string
+		call func() error
+		want error
+	}{
+		{"start container", func() error {
+			return conn.StartService(ctx, "missing")
+		}, ContainerDoesntExistErr},
+		{"remove container", func() error {
+			return conn.RemoveContainer(ctx, "missing", false)
+		}, ContainerDoesntExistErr},
+		{"create from missing image", func() error {
+			return conn.CreateContainer(ctx, Config{
+				ContainerName: "web",
+				ImageURL:      "docker.io/library/busybox",
+			})
+		}, ImageDoesntExistErr},
+		{"remove image", func() error {
+			return conn.RemoveImage(ctx, "docker.io/library/busybox")
+		}, ImageDoesntExistErr},
+		{"remove volume", func() error {
+			return conn.RemoveVolume(ctx, "missing", false)
+		}, VolumeDoesntExistErr},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.call()
+			if !errors.Is(err, test.want) {
+				t.Fatalf("error = %v, want %v", err, test.want)
+			}
+			if !strings.Contains(err.Error(), "no such object") {
+				t.Errorf("error %q lost the message of the engine", err)
+			}
+		})
+	}
+
+	_, err := conn.NetworkSubnets(ctx, "broken")
+	if err == nil || !strings.Contains(err.Error(), "engine broke") {
+		t.Errorf("NetworkSubnets error = %v, want message of the engine", err)
+	}
+	for _, notFound := range []error{ContainerDoesntExistErr, ImageDoesntExistErr, VolumeDoesntExistErr} {
+		if errors.Is(err, notFound) {
+			t.Errorf("server error reported as %v", notFound)
+		}
+	}
+}
+
+func TestDockerCreateContainerRequest(t *testing.T) {
+	var created dockerCreateContainer
+	var name string
+	conn := newTestDocker(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/containers/web/json":
+			http.NotFound(w, r)
+		case r.Method == http.MethodPost && r.URL.Path == "/containers/create":
+			name = r.URL.Query().Get("name")
+			if err := json.NewDecoder(r.Body).Decode(&created); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			io.WriteString(w, `{"Id": "abc"}`)
+		default:
+			http.Error(w, "unexpected "+r.Method+" "+r.URL.Path, http.StatusTeapot)
+		}
+	}))
+
+	err := conn.CreateContainer(t.Context(), Config{
+		ContainerName:   "web",
+		ApplicationName: "Web",
+		ImageURL:        "docker.io/library/busybox",
+		ImageVersion:    "latest",
+		NetworkName:     "rocket-test",
+		BindPorts:       map[int]int{8080: 80},
+		EnvValues:       map[string]string{"GREETING": "hello"},
+		MountDirs: MountList{{
+			Type:        MountBind,
+			Source:      "/srv/web",
+			Destination: "/data",
+			ReadOnly:    true,
+		}},
+		Lifecycle: Lifecycle{Restart: RestartOnFailure, MaxRetries: 3, StopTimeout: "30s"},
+	})
+	if err != nil {
+		t.Fatalf("CreateContainer: %v", err)
+	}
+
+	if name != "web" {
+		t.Errorf("created as %q, want web", name)
+	}
+	if created.Image != "docker.io/library/busybox:latest" {
+		t.Errorf("Image = %q", created.Image)
+	}
+	if !slices.Contains(created.Env, "GREETING=hello") {
+		t.Errorf("Env = %v, missing GREETING=hello", created.Env)
+	}
+	if !slices.IsSorted(created.Env) {
+		t.Errorf("Env = %v, want sorted", created.Env)
+	}
+	if created.Labels[LabelManaged] != "true" || created.Labels[LabelApp] != "web" {
+		t.Errorf("Labels = %v, want rocket labels", created.Labels)
+	}
+	if _, ok := created.ExposedPorts["80/tcp"]; !ok {
+		t.Errorf("ExposedPorts = %v, missing 80/tcp", created.ExposedPorts)
+	}
+	bindings := created.HostConfig.PortBindings["80/tcp"]
+	if len(bindings) != 1 || bindings[0].HostPort != "8080" {
+		t.Errorf("PortBindings = %v, want 8080 -> 80/tcp", created.HostConfig.PortBindings)
+	}
+	if !slices.Equal(created.HostConfig.Binds, []string{"/srv/web:/data:ro"}) {
+		t.Errorf("Binds = %v", created.HostConfig.Binds)
+	}
+	if _, ok := created.NetworkingConfig.EndpointsConfig["rocket-test"]; !ok {
+		t.Errorf("EndpointsConfig = %v, missing rocket-test", created.NetworkingConfig.EndpointsConfig)
+	}
+	policy := created.HostConfig.RestartPolicy
+	if policy.Name != RestartOnFailure || policy.MaximumRetryCount != 3 {
+		t.Errorf("RestartPolicy = %+v", policy)
+	}
+	if created.StopTimeout == nil || *created.StopTimeout != 30 {
+		t.Errorf("StopTimeout = %v, want 30", created.StopTimeout)
+	}
+}
+
+func TestDockerExecRequest(t *testing.T) {
+	var session dockerExecCreate
+	conn := newTestDocker(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/containers/web/exec":
+			if err := json.NewDecoder(r.Body).Decode(&session); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			io.WriteString(w, `{"Id": "session"}`)
+		case r.Method == http.MethodPost && r.URL.Path == "/exec/session/start":
+			var start dockerExecStart
+			if err := json.NewDecoder(r.Body).Decode(&start); err != nil || start.Tty {
+				http.Error(w, "unexpected start", http.StatusBadRequest)
+				return
+			}
+			if r.Header.Get("Upgrade") != "tcp" {
+				http.Error(w, "not upgraded", http.StatusBadRequest)
+				return
+			}
+			raw, buffered, err := http.NewResponseController(w).Hijack()
+			if err != nil {
+				t.Errorf("Hijack: %v", err)
+				return
+			}
+			defer raw.Close()
+			buffered.WriteString("HTTP/1.1 101 UPGRADED\r\n" +
+				"Content-Type: application/vnd.docker.raw-stream\r\n" +
+				"Connection: Upgrade\r\nUpgrade: tcp\r\n\r\n")
+			buffered.Write(dockerFrame(1, "hello\n"))
+			buffered.Write(dockerFrame(2, "oops\n"))
+			buffered.Flush()
+		case r.Method == http.MethodGet && r.URL.Path == "/exec/session/json":
+			io.WriteString(w, `{"ExitCode": 3}`)
+		default:
+			http.Error(w, "unexpected "+r.Method+" "+r.URL.Path, http.StatusTeapot)
+		}
+	}))
+
+	var stdout, stderr bytes.Buffer
+	exitCode, err := conn.Exec(t.Context(), "web", ExecOptions{
+		Cmd:    []string{"sh", "-c", "echo hello"},
+		Stdout: &stdout,
+		Stderr: &stderr,
+	})
+	if err != nil {
+		t.Fatalf("Exec: %v", err)
+	}
+
+	want := dockerExecCreate{
+		AttachStdout: true,
+		AttachStderr: true,
+		Cmd:          []string{"sh", "-c", "echo hello"},
+	}
+	if !slices.Equal(session.Cmd, want.Cmd) ||
+		session.AttachStdin || !session.AttachStdout || !session.AttachStderr || session.Tty {
+		t.Errorf("exec session = %+v, want %+v", session, want)
+	}
+	if exitCode != 3 {
+		t.Errorf("exit code = %d, want 3", exitCode)
+	}
+	if stdout.String() != "hello\n" || stderr.String() != "oops\n" {
+		t.Errorf("stdout = %q, stderr = %q", stdout.String(), stderr.String())
+	}
+}
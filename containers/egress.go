@@ -0,0 +1,115 @@
+package containers
+
+import (
+	"fmt"
+	"strings"
+
+	"ayayushsharma/rocket/common"
+	"ayayushsharma/rocket/constants"
+)
+
+// Egress policies of apps. The routes network is internal, so apps reach
+// nothing outside of it unless their policy allows
+const (
+	// no outbound traffic. Default
+	EgressNone = "none"
+	// app also joins the egress network and reaches any host
+	EgressInternet = "internet"
+	// outbound traffic goes through the egress proxy, which only lets hosts
+	// of the allowlist through
+	EgressAllowlist = "allowlist"
+)
+
+// Outbound traffic the app is allowed
+type Egress struct {
+	// one of "none", "internet" or "allowlist". Defaults to "none"
+	Policy string `json:",omitempty"`
+
+	// hosts reachable under the "allowlist" policy e.g. "api.github.com".
+	// "*.example.com" allows every subdomain of example.com
+	Allow []string `json:",omitempty"`
+}
+
+func (e Egress) IsZero() bool {
+	return e.Policy == "" && len(e.Allow) == 0
+}
+
+// Policy with the default applied
+func (e Egress) Mode() string {
+	if e.Policy == "" {
+		return EgressNone
+	}
+	return e.Policy
+}
+
+func (e Egress) String() string {
+	if e.Mode() == EgressAllowlist {
+		return fmt.Sprintf("%s(%s)", EgressAllowlist, strings.Join(e.Allow, ","))
+	}
+	return e.Mode()
+}
+
+func (e Egress) validate() error {
+	switch e.Mode() {
+	case EgressNone, EgressInternet:
+		if len(e.Allow) > 0 {
+			return fmt.Errorf("egress allowlist set with policy %q", e.Mode())
+		}
+	case EgressAllowlist:
+		if len(e.Allow) == 0 {
+			return fmt.Errorf("egress policy %q without hosts", e.Mode())
+		}
+	default:
+		return fmt.Errorf("unknown egress policy %q", e.Policy)
+	}
+
+	for _, host := range e.Allow {
+		if host == "" || strings.ContainsAny(host, " \t\n/:") {
+			return fmt.Errorf("invalid egress host %q", host)
+		}
+	}
+	return nil
+}
+
+// Networks the container joins, routes network first. Containers of a
+// stack join none, they use the network of the pod
+func (c Config) Networks() (networks []string) {
+	if c.NetworkName == "" {
+		return nil
+	}
+	networks = append(networks, c.NetworkName)
+	switch c.Egress.Mode() {
+	case EgressInternet:
+		networks = append(networks, constants.EgressNetwork)
+	case EgressAllowlist:
+		networks = append(networks, EgressProxyNetwork(c.owner()))
+	}
+	return append(networks, c.ProxyNetworks...)
+}
+
+// Internal network only the app and the egress proxy join. The proxy tells
+// requests of the app apart by the subnet of this network, which no other
+// container is on
+func EgressProxyNetwork(appName string) string {
+	return constants.EgressNetwork + "-" + common.ShortenAppName(appName)
+}
+
+// Proxy variables pointing apps with an allowlist to their port of the
+// egress proxy. Common lower case spellings are set as well
+func (c Config) proxyEnvironment() map[string]string {
+	if c.EgressProxy == "" {
+		return nil
+	}
+
+	noProxy := strings.Join(
+		[]string{"localhost", "127.0.0.1", constants.RouterContainer}, ",",
+	)
+	return map[string]string{
+		"HTTP_PROXY":  c.EgressProxy,
+		"HTTPS_PROXY": c.EgressProxy,
+		"NO_PROXY":    noProxy,
+		"http_proxy":  c.EgressProxy,
+		"https_proxy": c.EgressProxy,
+		"no_proxy":    noProxy,
+	}
+}
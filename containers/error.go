@@ -4,3 +4,8 @@ import "errors"
 
 var ContainerAlreadyExistsErr = errors.New("container already exists")
 var ContainerDoesntExistErr = errors.New("container does not exist")
+var VolumeDoesntExistErr = errors.New("volume does not exist")
+var ImageDoesntExistErr = errors.New("image does not exist")
+var UnknownRuntimeErr = errors.New("unknown container runtime")
+var PodsUnsupportedErr = errors.New("pods are not supported by the container runtime")
+var IdentityUnsupportedErr = errors.New("user namespace or ownership option is not supported by the container runtime")
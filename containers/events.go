@@ -0,0 +1,71 @@
+package containers
+
+import (
+	"strconv"
+	"time"
+)
+
+// Container actions reported as events. Runtimes spelling them differently
+// are mapped onto these
+const (
+	EventStart  = "start"
+	EventStop   = "stop"
+	EventKill   = "kill"
+	EventDie    = "die"
+	EventRemove = "remove"
+)
+
+// Lifecycle event of a container managed by rocket
+type Event struct {
+	Action    string
+	Container string
+
+	// workspace entry owning the container
+	App string
+
+	// exit code of the container, for "die" events only
+	ExitCode int
+
+	Time time.Time
+}
+
+// Action names of podman and docker that differ from the ones of Event
+var eventActions = map[string]string{
+	"died":    EventDie,
+	"destroy": EventRemove,
+}
+
+// Builds the event from the action and actor attributes reported by the
+// runtime. Events of other actions are dropped
+func newEvent(action string, attributes map[string]string, at time.Time) (
+	event Event, ok bool,
+) {
+	if mapped, known := eventActions[action]; known {
+		action = mapped
+	}
+	switch action {
+	case EventStart, EventStop, EventKill, EventDie, EventRemove:
+	default:
+		return event, false
+	}
+
+	event = Event{
+		Action:    action,
+		Container: attributes["name"],
+		App:       attributes[LabelApp],
+		Time:      at,
+	}
+	// docker and podman name the exit code attribute differently
+	for _, key := range []string{"exitCode", "containerExitCode"} {
+		if code, err := strconv.Atoi(attributes[key]); err == nil {
+			event.ExitCode = code
+			break
+		}
+	}
+	return event, true
+}
+
+// Crashes are deaths with a non-zero exit code
+func (e Event) Crashed() bool {
+	return e.Action == EventDie && e.ExitCode != 0
+}
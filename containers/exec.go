@@ -0,0 +1,19 @@
+package containers
+
+import "io"
+
+// Options for running a command inside a running container
+type ExecOptions struct {
+	// command along with its arguments
+	Cmd []string
+
+	// allocate a pseudo terminal for the command
+	Tty bool
+
+	// stream Stdin to the command
+	Interactive bool
+
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+}
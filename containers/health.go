@@ -0,0 +1,104 @@
+package containers
+
+import (
+	"fmt"
+	"time"
+)
+
+// Health states reported by runtimes for containers with a healthcheck
+const (
+	HealthStarting  = "starting"
+	HealthHealthy   = "healthy"
+	HealthUnhealthy = "unhealthy"
+)
+
+// Healthcheck run periodically by the container runtime inside the container.
+// Either HttpPath or Command is used, HttpPath takes precedence
+type Healthcheck struct {
+	// path requested on ExposeHttpPort e.g. "/healthz"
+	HttpPath string `json:",omitempty"`
+
+	// command run inside the container, healthy on exit code 0
+	Command []string `json:",omitempty"`
+
+	// time between checks e.g. "30s". Defaults to 30s
+	Interval string `json:",omitempty"`
+
+	// time a single check may take e.g. "5s". Defaults to 5s
+	Timeout string `json:",omitempty"`
+
+	// grace period after start when failures are not counted e.g. "10s"
+	StartPeriod string `json:",omitempty"`
+
+	// consecutive failures needed to mark container unhealthy. Defaults to 3
+	Retries int `json:",omitempty"`
+}
+
+func (h Healthcheck) IsZero() bool {
+	return h.HttpPath == "" && len(h.Command) == 0
+}
+
+// Healthcheck with defaults applied and durations parsed
+type healthConfig struct {
+	Test        []string
+	Interval    time.Duration
+	Timeout     time.Duration
+	StartPeriod time.Duration
+	Retries     int
+}
+
+// Resolves the healthcheck of options. Returns nil when none is defined
+func (c Config) healthConfig() (*healthConfig, error) {
+	check := c.Healthcheck
+	if check.IsZero() {
+		return nil, nil
+	}
+
+	health := &healthConfig{
+		Interval: 30 * time.Second,
+		Timeout:  5 * time.Second,
+		Retries:  3,
+	}
+
+	if check.HttpPath != "" {
+		if c.ExposeHttpPort == 0 {
+			return nil, fmt.Errorf("http healthcheck needs ExposeHttpPort to be set")
+		}
+		// images ship either wget or curl, try both
+		endpoint := fmt.Sprintf("http://127.0.0.1:%d%s", c.ExposeHttpPort, check.HttpPath)
+		health.Test = []string{
+			"CMD-SHELL",
+			fmt.Sprintf(
+				"wget -q -O /dev/null %[1]s || curl -fsS -o /dev/null %[1]s || exit 1",
+				endpoint,
+			),
+		}
+	} else {
+		health.Test = append([]string{"CMD"}, check.Command...)
+	}
+
+	durations := []struct {
+		value  string
+		target *time.Duration
+	}{
+		{check.Interval, &health.Interval},
+		{check.Timeout, &health.Timeout},
+		{check.StartPeriod, &health.StartPeriod},
+	}
+	for _, duration := range durations {
+		if duration.value == "" {
+			continue
+		}
+		parsed, err := time.ParseDuration(duration.value)
+		if err != nil {
+			return nil, fmt.Errorf("healthcheck: %w", err)
+		}
+		*duration.target = parsed
+	}
+
+	if check.Retries > 0 {
+		health.Retries = check.Retries
+	}
+
+	return health, nil
+}
@@ -0,0 +1,145 @@
+package containers
+
+import (
+	"fmt"
+	"strings"
+)
+
+// User namespace modes. Podman accepts options after a colon e.g.
+// "keep-id:uid=1000,gid=1000" or "auto:size=65536"
+const (
+	// share the user namespace of the host, or of the user running rootless
+	// podman
+	UsernsHost = "host"
+	// map the user running rootless podman to the same uid in the container,
+	// so files written to bind mounts stay owned by them
+	UsernsKeepID = "keep-id"
+	// private namespace with a free range of subordinate ids picked by podman
+	UsernsAuto = "auto"
+	// do not map the user running rootless podman into the container
+	UsernsNoMap = "nomap"
+)
+
+// Range of ids mapped into a private user namespace
+type IDMap struct {
+	// first id inside the container
+	ContainerID int
+
+	// first id outside the container. Rootless podman maps it relative to the
+	// subordinate ids of the user
+	HostID int
+
+	// number of ids mapped
+	Size int
+}
+
+// User namespace, user and groups of the container. Zero values keep the
+// defaults of the runtime and image
+type Identity struct {
+	// one of "host", "keep-id", "auto" or "nomap", with podman options after a
+	// colon. Cannot be combined with UIDMap or GIDMap. Containers of a stack
+	// share the user namespace of the app
+	UserNS string `json:",omitempty"`
+
+	// uid ranges of a private user namespace
+	UIDMap []IDMap `json:",omitempty"`
+
+	// gid ranges of a private user namespace. Same as UIDMap if empty
+	GIDMap []IDMap `json:",omitempty"`
+
+	// user name or uid processes of the container run as
+	User string `json:",omitempty"`
+
+	// group name or gid processes run as. Primary group of User if empty
+	Group string `json:",omitempty"`
+
+	// supplementary groups of the processes
+	GroupAdd []string `json:",omitempty"`
+}
+
+func (i Identity) IsZero() bool {
+	return i.UserNS == "" &&
+		len(i.UIDMap) == 0 &&
+		len(i.GIDMap) == 0 &&
+		i.User == "" &&
+		i.Group == "" &&
+		len(i.GroupAdd) == 0
+}
+
+func (i Identity) validate() error {
+	mode, _, hasOptions := strings.Cut(i.UserNS, ":")
+	switch mode {
+	case UsernsKeepID, UsernsAuto:
+	case UsernsHost, UsernsNoMap:
+		if hasOptions {
+			return fmt.Errorf("user namespace %q takes no options", i.UserNS)
+		}
+	case "":
+		if i.UserNS != "" {
+			return fmt.Errorf("user namespace %q has no mode", i.UserNS)
+		}
+	default:
+		return fmt.Errorf("unknown user namespace mode %q", i.UserNS)
+	}
+
+	if i.UserNS != "" && i.hasIDMaps() {
+		return fmt.Errorf("user namespace %q cannot be combined with id maps", i.UserNS)
+	}
+	for _, idMap := range append(i.UIDMap, i.GIDMap...) {
+		if idMap.ContainerID < 0 || idMap.HostID < 0 || idMap.Size <= 0 {
+			return fmt.Errorf(
+				"invalid id map %d:%d:%d",
+				idMap.ContainerID, idMap.HostID, idMap.Size,
+			)
+		}
+	}
+
+	if i.User != "" && !validID(i.User) {
+		return fmt.Errorf("invalid user %q", i.User)
+	}
+	if i.Group != "" && !validID(i.Group) {
+		return fmt.Errorf("invalid group %q", i.Group)
+	}
+	if i.Group != "" && i.User == "" {
+		return fmt.Errorf("group %q set without user", i.Group)
+	}
+	for _, group := range i.GroupAdd {
+		if !validID(group) {
+			return fmt.Errorf("invalid supplementary group %q", group)
+		}
+	}
+	return nil
+}
+
+// Whether the user or group name or id can be passed to the runtimes. Colons
+// would split it into a user and group
+func validID(id string) bool {
+	return id != "" &&
+		!strings.HasPrefix(id, "-") &&
+		!strings.ContainsAny(id, ": \t\n")
+}
+
+// Mode of the user namespace without its options
+func (i Identity) usernsMode() string {
+	mode, _, _ := strings.Cut(i.UserNS, ":")
+	return mode
+}
+
+func (i Identity) hasIDMaps() bool {
+	return len(i.UIDMap) > 0 || len(i.GIDMap) > 0
+}
+
+func (i Identity) gidMap() []IDMap {
+	if len(i.GIDMap) == 0 {
+		return i.UIDMap
+	}
+	return i.GIDMap
+}
+
+// User and group in the "user:group" syntax of both runtimes
+func (i Identity) userSpec() string {
+	if i.Group == "" {
+		return i.User
+	}
+	return i.User + ":" + i.Group
+}
@@ -0,0 +1,139 @@
+package containers
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/containers/podman/v6/pkg/specgen"
+	"go.podman.io/storage/pkg/idtools"
+	storagetypes "go.podman.io/storage/types"
+)
+
+func TestIdentityValidate(t *testing.T) {
+	valid := map[string]Identity{
+		"zero":             {},
+		"user":             {User: "app"},
+		"uid and gid":      {User: "1000", Group: "1000"},
+		"user and group":   {User: "app", Group: "staff"},
+		"groups":           {GroupAdd: []string{"video", "44", "keep-groups"}},
+		"keep-id":          {UserNS: UsernsKeepID},
+		"keep-id options":  {UserNS: "keep-id:uid=1000,gid=1000"},
+		"auto options":     {UserNS: "auto:size=65536"},
+		"host":             {UserNS: UsernsHost, User: "root"},
+		"nomap":            {UserNS: UsernsNoMap},
+		"uid map":          {UIDMap: []IDMap{{ContainerID: 0, HostID: 1, Size: 65536}}},
+		"uid and gid maps": {UIDMap: []IDMap{{0, 1, 1000}}, GIDMap: []IDMap{{0, 1, 500}}},
+	}
+	for name, identity := range valid {
+		if err := identity.validate(); err != nil {
+			t.Errorf("%s: validate() = %v, want valid", name, err)
+		}
+	}
+
+	invalid := map[string]Identity{
+		"unknown userns":       {UserNS: "private"},
+		"userns options only":  {UserNS: ":size=10"},
+		"host with options":    {UserNS: "host:size=10"},
+		"userns and uid map":   {UserNS: UsernsKeepID, UIDMap: []IDMap{{0, 1, 10}}},
+		"userns and gid map":   {UserNS: UsernsAuto, GIDMap: []IDMap{{0, 1, 10}}},
+		"empty map":            {UIDMap: []IDMap{{0, 1, 0}}},
+		"negative host id":     {GIDMap: []IDMap{{0, -1, 10}}},
+		"negative container":   {UIDMap: []IDMap{{-1, 0, 10}}},
+		"group without user":   {Group: "staff"},
+		"user with group":      {User: "app:staff"},
+		"group with colon":     {User: "app", Group: "staff:x"},
+		"user with space":      {User: "my app"},
+		"user as flag":         {User: "-1"},
+		"group as flag":        {User: "app", Group: "--privileged"},
+		"empty extra group":    {GroupAdd: []string{"video", ""}},
+		"extra group newline":  {GroupAdd: []string{"video\naudio"}},
+		"extra group with gid": {GroupAdd: []string{"video:44"}},
+	}
+	for name, identity := range invalid {
+		if err := identity.validate(); err == nil {
+			t.Errorf("%s: validate() accepted %+v", name, identity)
+		}
+	}
+}
+
+func TestPodmanUserNS(t *testing.T) {
+	tests := []struct {
+		name       string
+		identity   Identity
+		want       specgen.Namespace
+		idMappings *storagetypes.IDMappingOptions
+	}{
+		{
+			name: "runtime default",
+		},
+		{
+			name:     "keep-id",
+			identity: Identity{UserNS: UsernsKeepID},
+			want:     specgen.Namespace{NSMode: specgen.KeepID},
+		},
+		{
+			name:     "keep-id options",
+			identity: Identity{UserNS: "keep-id:uid=1000,gid=1000"},
+			want:     specgen.Namespace{NSMode: specgen.KeepID, Value: "uid=1000,gid=1000"},
+		},
+		{
+			name:     "auto",
+			identity: Identity{UserNS: UsernsAuto},
+			want:     specgen.Namespace{NSMode: specgen.Auto},
+		},
+		{
+			name:     "auto options",
+			identity: Identity{UserNS: "auto:size=65536"},
+			want:     specgen.Namespace{NSMode: specgen.Auto, Value: "size=65536"},
+		},
+		{
+			name:     "host",
+			identity: Identity{UserNS: UsernsHost},
+			want:     specgen.Namespace{NSMode: specgen.Host},
+		},
+		{
+			name:     "nomap",
+			identity: Identity{UserNS: UsernsNoMap},
+			want:     specgen.Namespace{NSMode: specgen.NoMap},
+		},
+		{
+			name:     "gid map defaults to uid map",
+			identity: Identity{UIDMap: []IDMap{{0, 100000, 65536}}},
+			want:     specgen.Namespace{NSMode: specgen.Private},
+			idMappings: &storagetypes.IDMappingOptions{
+				UIDMap: []idtools.IDMap{{ContainerID: 0, HostID: 100000, Size: 65536}},
+				GIDMap: []idtools.IDMap{{ContainerID: 0, HostID: 100000, Size: 65536}},
+			},
+		},
+		{
+			name: "separate gid map",
+			identity: Identity{
+				UIDMap: []IDMap{{0, 1, 1000}},
+				GIDMap: []IDMap{{0, 1, 500}, {500, 2000, 10}},
+			},
+			want: specgen.Namespace{NSMode: specgen.Private},
+			idMappings: &storagetypes.IDMappingOptions{
+				UIDMap: []idtools.IDMap{{ContainerID: 0, HostID: 1, Size: 1000}},
+				GIDMap: []idtools.IDMap{
+					{ContainerID: 0, HostID: 1, Size: 500},
+					{ContainerID: 500, HostID: 2000, Size: 10},
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			userNS, idMappings, err := podmanUserNS(test.identity)
+			if err != nil {
+				t.Fatalf("podmanUserNS: %v", err)
+			}
+			if userNS != test.want {
+				t.Errorf("user namespace = %+v, want %+v", userNS, test.want)
+			}
+			if !reflect.DeepEqual(idMappings, test.idMappings) {
+				t.Errorf("id mappings = %+v, want %+v", idMappings, test.idMappings)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package containers
+
+import (
+	"cmp"
+
+	"go.podman.io/image/v5/docker/reference"
+)
+
+// Image as stored by the container runtime
+type ImageDetails struct {
+	ID string
+
+	// digest the image was pulled by from its repository e.g. "sha256:...".
+	// For multi-platform images this is the digest of the manifest list, so
+	// that it names the same image on every runtime and platform. Empty for
+	// images that were never pulled from a registry
+	Digest string
+
+	// bytes the image takes on disk
+	Size int64
+}
+
+// Digest of the manifest list or manifest the image was pulled by from the
+// repository of imageName, or the digest imageName is pinned to. repoDigests are the "repo@sha256:..." references
+// the runtime recorded for the image and platformDigest the digest of the
+// manifest of the local platform, which is only taken when the repository
+// recorded no other
+func pulledDigest(imageName string, repoDigests []string, platformDigest string) string {
+	named, err := reference.ParseNormalizedNamed(imageName)
+	if err != nil {
+		return platformDigest
+	}
+	// images pulled by digest keep it
+	if digested, ok := named.(reference.Digested); ok {
+		return digested.Digest().String()
+	}
+
+	found := ""
+	for _, repoDigest := range repoDigests {
+		canonical, err := reference.ParseNormalizedNamed(repoDigest)
+		if err != nil || canonical.Name() != named.Name() {
+			continue
+		}
+		digested, ok := canonical.(reference.Digested)
+		if !ok {
+			continue
+		}
+		digest := digested.Digest().String()
+		if digest != platformDigest {
+			return digest
+		}
+		found = digest
+	}
+	return cmp.Or(found, platformDigest)
+}
@@ -0,0 +1,75 @@
+package containers
+
+import "testing"
+
+func TestPulledDigest(t *testing.T) {
+	const (
+		listDigest     = "sha256:1111111111111111111111111111111111111111111111111111111111111111"
+		platformDigest = "sha256:2222222222222222222222222222222222222222222222222222222222222222"
+		otherDigest    = "sha256:3333333333333333333333333333333333333333333333333333333333333333"
+	)
+
+	tests := []struct {
+		name           string
+		repoDigests    []string
+		platformDigest string
+		want           string
+	}{
+		{
+			name: "podman prefers the manifest list",
+			repoDigests: []string{
+				"docker.io/library/busybox@" + platformDigest,
+				"docker.io/library/busybox@" + listDigest,
+			},
+			platformDigest: platformDigest,
+			want:           listDigest,
+		},
+		{
+			name:           "single platform image",
+			repoDigests:    []string{"docker.io/library/busybox@" + platformDigest},
+			platformDigest: platformDigest,
+			want:           platformDigest,
+		},
+		{
+			name:        "docker short names",
+			repoDigests: []string{"busybox@" + listDigest},
+			want:        listDigest,
+		},
+		{
+			name: "other repositories skipped",
+			repoDigests: []string{
+				"quay.io/mirror/busybox@" + otherDigest,
+				"busybox@" + listDigest,
+			},
+			want: listDigest,
+		},
+		{
+			name:           "no repo digest",
+			platformDigest: platformDigest,
+			want:           platformDigest,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := pulledDigest(
+				"docker.io/library/busybox:latest", test.repoDigests, test.platformDigest,
+			)
+			if got != test.want {
+				t.Errorf("pulledDigest() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestPulledDigestOfPinnedImage(t *testing.T) {
+	const pinned = "sha256:4444444444444444444444444444444444444444444444444444444444444444"
+	repoDigests := []string{
+		"docker.io/library/busybox@sha256:1111111111111111111111111111111111111111111111111111111111111111",
+	}
+
+	got := pulledDigest("docker.io/library/busybox@"+pinned, repoDigests, "")
+	if got != pinned {
+		t.Errorf("pulledDigest() = %q, want pinned %q", got, pinned)
+	}
+}
@@ -1,25 +1,82 @@
 package containers
 
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+// Container runtimes rocket can talk to
+const (
+	RuntimePodman = "podman"
+	RuntimeDocker = "docker"
+)
+
 type ContainerManager interface {
-	PullImage(imageName string) error
-	RemoveImage(imageName string) error
-	ImageExists(imageName string) (bool, error)
-
-	ListContainers() ([]string, error)
-	CreateContainer(options Config) error
-	RemoveContainer(containerName string, force bool) error
-	ContainerExists(containerName string) (bool, error)
-
-	StartService(containerName string) error
-	PauseService(containerName string) error
-	StopService(containerName string) error
-	UpdateService(containerName string) error
-
-	ListNetworks() ([]string, error)
-	CreateNetwork(networkName string) error
-	NetworkExists(networkName string) (bool, error)
+	PullImage(ctx context.Context, imageName string, options PullOptions) error
+	RemoveImage(ctx context.Context, imageName string) error
+	ImageExists(ctx context.Context, imageName string) (bool, error)
+	InspectImage(ctx context.Context, imageName string) (ImageDetails, error)
+
+	ListContainers(ctx context.Context) ([]string, error)
+	ListManagedContainers(ctx context.Context) ([]ManagedContainer, error)
+	CreateContainer(ctx context.Context, options Config) error
+	RemoveContainer(ctx context.Context, containerName string, force bool) error
+	ContainerExists(ctx context.Context, containerName string) (bool, error)
+	InspectContainer(ctx context.Context, containerName string) (ContainerStatus, error)
+	Logs(
+		ctx context.Context,
+		containerName string,
+		options LogOptions,
+		stdout, stderr io.Writer,
+	) error
+	Exec(ctx context.Context, containerName string, options ExecOptions) (exitCode int, err error)
+	Events(ctx context.Context, handle func(Event)) error
+	Stats(ctx context.Context, containerNames []string) ([]ContainerStats, error)
+
+	StartService(ctx context.Context, containerName string) error
+	PauseService(ctx context.Context, containerName string) error
+	UnpauseService(ctx context.Context, containerName string) error
+	StopService(ctx context.Context, containerName string) error
+	UpdateService(ctx context.Context, options Config) (updated bool, err error)
+
+	CreatePod(ctx context.Context, options Config) error
+	StartPod(ctx context.Context, podName string) error
+	StopPod(ctx context.Context, podName string) error
+	RemovePod(ctx context.Context, podName string, force bool) error
+	PodExists(ctx context.Context, podName string) (bool, error)
+	ListManagedPods(ctx context.Context) ([]ManagedPod, error)
+
+	ListManagedVolumes(ctx context.Context) ([]ManagedVolume, error)
+	RemoveVolume(ctx context.Context, volumeName string, force bool) error
+	ExportVolume(ctx context.Context, volumeName string, contents io.Writer) error
+	ImportVolume(ctx context.Context, volumeName string, contents io.Reader) error
+
+	ListNetworks(ctx context.Context) ([]string, error)
+	CreateNetwork(ctx context.Context, networkName string, internal bool) error
+	NetworkExists(ctx context.Context, networkName string) (bool, error)
+	NetworkSubnets(ctx context.Context, networkName string) ([]string, error)
+}
+
+// Options for connecting to a container runtime
+type ConnectOptions struct {
+	// container runtime to use. Defaults to podman
+	Runtime string
+
+	// name of podman system connection to use instead of the default one
+	Connection string
+
+	// limits on pulls, creates and stops issued through the manager
+	Timeouts Timeouts
 }
 
-func Manager() (manager ContainerManager, err error) {
-	return connectPodman()
+// Returns the manager for the container runtime in options
+func Manager(options ConnectOptions) (manager ContainerManager, err error) {
+	switch options.Runtime {
+	case "", RuntimePodman:
+		return connectPodman(options)
+	case RuntimeDocker:
+		return connectDocker(options)
+	}
+	return nil, fmt.Errorf("%w: %q", UnknownRuntimeErr, options.Runtime)
 }
@@ -0,0 +1,105 @@
+package containers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+)
+
+// Labels rocket puts on everything it creates
+const (
+	LabelAppName   = "app.name"
+	LabelContainer = "app.container"
+	LabelSubDomain = "app.subdomain"
+
+	// marks containers, pods and volumes as created by rocket
+	LabelManaged = "rocket.managed"
+
+	// workspace entry the object belongs to. For containers of a stack this
+	// is the container name of the app owning the stack
+	LabelApp = "rocket.app"
+
+	// hash of the config the container was created from
+	LabelConfigHash = "rocket.config-hash"
+)
+
+// Filter selecting objects created by rocket
+const managedFilter = LabelManaged + "=true"
+
+// Container created by rocket as found in the container runtime
+type ManagedContainer struct {
+	Name   string
+	State  string
+	Labels map[string]string
+}
+
+// Workspace entry the container belongs to
+func (c ManagedContainer) App() string {
+	return c.Labels[LabelApp]
+}
+
+func (c ManagedContainer) ConfigHash() string {
+	return c.Labels[LabelConfigHash]
+}
+
+func (c ManagedContainer) Running() bool {
+	return c.State == StateRunning
+}
+
+// Pod created by rocket as found in the container runtime
+type ManagedPod struct {
+	Name   string
+	Labels map[string]string
+}
+
+// Workspace entry owning the pod
+func (p ManagedPod) App() string {
+	return p.Labels[LabelApp]
+}
+
+// Volume created by rocket as found in the container runtime
+type ManagedVolume struct {
+	Name   string
+	Labels map[string]string
+}
+
+// Workspace entry the volume was created for
+func (v ManagedVolume) App() string {
+	return v.Labels[LabelApp]
+}
+
+// Hash of the config, used to detect containers created from an older config
+func (c Config) Hash() string {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])[:16]
+}
+
+// Workspace entry owning the config
+func (c Config) owner() string {
+	if c.Pod != "" {
+		return strings.TrimSuffix(c.Pod, podSuffix)
+	}
+	return c.ContainerName
+}
+
+// Labels marking objects as owned by the application
+func ownerLabels(options Config) map[string]string {
+	return map[string]string{
+		LabelAppName:   options.ApplicationName,
+		LabelContainer: options.ContainerName,
+		LabelManaged:   "true",
+		LabelApp:       options.owner(),
+	}
+}
+
+func containerLabels(options Config) map[string]string {
+	labels := ownerLabels(options)
+	labels[LabelSubDomain] = options.SubDomain
+	labels[LabelConfigHash] = options.Hash()
+	return labels
+}
@@ -0,0 +1,97 @@
+package containers
+
+import (
+	"fmt"
+	"syscall"
+	"time"
+
+	"go.podman.io/common/pkg/signal"
+)
+
+// Restart policies of containers
+const (
+	// never restarted
+	RestartNo = "no"
+	// restarted when exiting with a non-zero code, up to MaxRetries times
+	RestartOnFailure = "on-failure"
+	// always restarted. Default
+	RestartAlways = "always"
+	// restarted unless stopped by hand, also across runtime restarts
+	RestartUnlessStopped = "unless-stopped"
+)
+
+// Restart and stop behaviour of the container. Zero values keep restarting
+// the container always and stopping it the way the runtime does
+type Lifecycle struct {
+	// one of "no", "on-failure", "always" or "unless-stopped". Defaults to
+	// "always"
+	Restart string `json:",omitempty"`
+
+	// restarts attempted under "on-failure" before giving up. Unlimited if 0
+	MaxRetries uint `json:",omitempty"`
+
+	// signal asking the container to stop e.g. "SIGINT". Image default if
+	// empty
+	StopSignal string `json:",omitempty"`
+
+	// time the container gets to exit after the stop signal before it is
+	// killed e.g. "2m". Runtime default if empty, `stop-timeout` overrides
+	// it when set
+	StopTimeout string `json:",omitempty"`
+}
+
+func (l Lifecycle) IsZero() bool {
+	return l == Lifecycle{}
+}
+
+// Restart policy with the default applied
+func (l Lifecycle) restartPolicy() string {
+	if l.Restart == "" {
+		return RestartAlways
+	}
+	return l.Restart
+}
+
+func (l Lifecycle) validate() error {
+	switch l.restartPolicy() {
+	case RestartNo, RestartAlways, RestartUnlessStopped:
+		if l.MaxRetries > 0 {
+			return fmt.Errorf("max retries set with restart policy %q", l.restartPolicy())
+		}
+	case RestartOnFailure:
+	default:
+		return fmt.Errorf("unknown restart policy %q", l.Restart)
+	}
+
+	if _, err := l.stopSignal(); err != nil {
+		return err
+	}
+	if _, err := l.stopSeconds(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Parsed stop signal, 0 when unset
+func (l Lifecycle) stopSignal() (syscall.Signal, error) {
+	if l.StopSignal == "" {
+		return 0, nil
+	}
+	sig, err := signal.ParseSignalNameOrNumber(l.StopSignal)
+	if err != nil {
+		return 0, fmt.Errorf("stop signal %q: %w", l.StopSignal, err)
+	}
+	return sig, nil
+}
+
+// Stop timeout in whole seconds, 0 when unset
+func (l Lifecycle) stopSeconds() (uint, error) {
+	if l.StopTimeout == "" {
+		return 0, nil
+	}
+	timeout, err := time.ParseDuration(l.StopTimeout)
+	if err != nil || timeout < 0 {
+		return 0, fmt.Errorf("invalid stop timeout %q", l.StopTimeout)
+	}
+	return uint(timeout.Round(time.Second) / time.Second), nil
+}
@@ -0,0 +1,133 @@
+package containers
+
+import (
+	"strings"
+	"syscall"
+	"testing"
+)
+
+func TestLifecycleValidate(t *testing.T) {
+	tests := []struct {
+		name      string
+		lifecycle Lifecycle
+		wantErr   string
+	}{
+		{name: "zero"},
+		{name: "no", lifecycle: Lifecycle{Restart: RestartNo}},
+		{name: "always", lifecycle: Lifecycle{Restart: RestartAlways}},
+		{name: "unless stopped", lifecycle: Lifecycle{Restart: RestartUnlessStopped}},
+		{name: "on failure", lifecycle: Lifecycle{Restart: RestartOnFailure}},
+		{
+			name:      "on failure with retries",
+			lifecycle: Lifecycle{Restart: RestartOnFailure, MaxRetries: 5},
+		},
+		{
+			name:      "unknown policy",
+			lifecycle: Lifecycle{Restart: "sometimes"},
+			wantErr:   `unknown restart policy "sometimes"`,
+		},
+		{
+			name:      "policy case",
+			lifecycle: Lifecycle{Restart: "Always"},
+			wantErr:   "unknown restart policy",
+		},
+		{
+			name:      "policy with retries suffix",
+			lifecycle: Lifecycle{Restart: "on-failure:3"},
+			wantErr:   "unknown restart policy",
+		},
+		{
+			name:      "retries with default policy",
+			lifecycle: Lifecycle{MaxRetries: 3},
+			wantErr:   `max retries set with restart policy "always"`,
+		},
+		{
+			name:      "retries with no policy",
+			lifecycle: Lifecycle{Restart: RestartNo, MaxRetries: 1},
+			wantErr:   "max retries",
+		},
+		{
+			name:      "retries with unless stopped",
+			lifecycle: Lifecycle{Restart: RestartUnlessStopped, MaxRetries: 1},
+			wantErr:   "max retries",
+		},
+		{name: "signal name", lifecycle: Lifecycle{StopSignal: "SIGINT"}},
+		{name: "signal without prefix", lifecycle: Lifecycle{StopSignal: "term"}},
+		{name: "signal number", lifecycle: Lifecycle{StopSignal: "15"}},
+		{
+			name:      "unknown signal",
+			lifecycle: Lifecycle{StopSignal: "SIGNOPE"},
+			wantErr:   "stop signal",
+		},
+		{name: "timeout", lifecycle: Lifecycle{StopTimeout: "2m"}},
+		{name: "zero timeout", lifecycle: Lifecycle{StopTimeout: "0s"}},
+		{
+			name:      "negative timeout",
+			lifecycle: Lifecycle{StopTimeout: "-5s"},
+			wantErr:   `invalid stop timeout "-5s"`,
+		},
+		{
+			name:      "timeout without unit",
+			lifecycle: Lifecycle{StopTimeout: "30"},
+			wantErr:   "invalid stop timeout",
+		},
+		{
+			name:      "timeout not a duration",
+			lifecycle: Lifecycle{StopTimeout: "soon"},
+			wantErr:   "invalid stop timeout",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.lifecycle.validate()
+			if test.wantErr == "" {
+				if err != nil {
+					t.Errorf("validate() = %v, want valid", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Errorf("validate() = %v, want error containing %q", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestLifecycleStop(t *testing.T) {
+	seconds := map[string]uint{
+		"":        0,
+		"30s":     30,
+		"2m":      120,
+		"1500ms":  2,
+		"1m29.4s": 89,
+	}
+	for timeout, want := range seconds {
+		got, err := Lifecycle{StopTimeout: timeout}.stopSeconds()
+		if err != nil || got != want {
+			t.Errorf("stopSeconds(%q) = %d, %v, want %d", timeout, got, err, want)
+		}
+	}
+
+	signals := map[string]syscall.Signal{
+		"":        0,
+		"SIGINT":  syscall.SIGINT,
+		"sigquit": syscall.SIGQUIT,
+		"9":       syscall.SIGKILL,
+	}
+	for name, want := range signals {
+		got, err := Lifecycle{StopSignal: name}.stopSignal()
+		if err != nil || got != want {
+			t.Errorf("stopSignal(%q) = %d, %v, want %d", name, got, err, want)
+		}
+	}
+}
+
+func TestRestartPolicyDefault(t *testing.T) {
+	if policy := (Lifecycle{}).restartPolicy(); policy != RestartAlways {
+		t.Errorf("default restart policy = %q, want %q", policy, RestartAlways)
+	}
+	if policy := (Lifecycle{Restart: RestartNo}).restartPolicy(); policy != RestartNo {
+		t.Errorf("restart policy = %q, want %q", policy, RestartNo)
+	}
+}
@@ -0,0 +1,32 @@
+package containers
+
+import (
+	"strconv"
+	"time"
+)
+
+// Options for reading logs of a container
+type LogOptions struct {
+	// keep streaming logs as they are written
+	Follow bool
+
+	// number of lines from the end of logs to show. Negative shows all
+	Tail int
+
+	// only show logs written after this time. Zero shows all
+	Since time.Time
+}
+
+func (o LogOptions) tail() string {
+	if o.Tail < 0 {
+		return "all"
+	}
+	return strconv.Itoa(o.Tail)
+}
+
+func (o LogOptions) since() string {
+	if o.Since.IsZero() {
+		return ""
+	}
+	return strconv.FormatInt(o.Since.Unix(), 10)
+}
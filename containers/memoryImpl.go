@@ -0,0 +1,1012 @@
+package containers
+
+import (
+	"archive/tar"
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// In memory container manager. Keeps track of images, containers and
+// networks without talking to any container runtime, mirroring the behaviour
+// of PodManContext. Used to exercise commands hermetically. Every method
+// fails with the context's error once the context is done.
+type MemoryManager struct {
+	mu         sync.Mutex
+	images     map[string]string
+	remote     map[string]string
+	containers map[string]*memoryContainer
+	networks   map[string]bool
+	subnets    map[string]string
+	volumes    map[string]map[string]string
+	volumeData map[string][]byte
+	pods       map[string]Config
+	watchers   map[chan Event]bool
+}
+
+type memoryContainer struct {
+	config    Config
+	image     string
+	imageID   string
+	state     string
+	startedAt time.Time
+	logs      []string
+	execs     [][]string
+	health    string
+	restarts  int
+}
+
+// States a container of MemoryManager moves through
+const (
+	MemoryStateCreated = "created"
+	MemoryStateRunning = "running"
+	MemoryStatePaused  = "paused"
+	MemoryStateExited  = "exited"
+)
+
+func NewMemoryManager() *MemoryManager {
+	return &MemoryManager{
+		images:     map[string]string{},
+		remote:     map[string]string{},
+		containers: map[string]*memoryContainer{},
+		networks:   map[string]bool{},
+		subnets:    map[string]string{},
+		volumes:    map[string]map[string]string{},
+		volumeData: map[string][]byte{},
+		pods:       map[string]Config{},
+		watchers:   map[chan Event]bool{},
+	}
+}
+
+// Moves the running container to exited as if its process died with the
+// exit code
+func (m *MemoryManager) Crash(containerName string, exitCode int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ctr, ok := m.containers[containerName]
+	if !ok {
+		return ContainerDoesntExistErr
+	}
+	if ctr.state != MemoryStateRunning {
+		return fmt.Errorf("container %q is not running", containerName)
+	}
+	ctr.state = MemoryStateExited
+	m.emit(EventDie, containerName, ctr, exitCode)
+	return nil
+}
+
+// Reports the event to every watcher. Called with the lock held, watchers
+// not keeping up miss events
+func (m *MemoryManager) emit(
+	action string,
+	containerName string,
+	ctr *memoryContainer,
+	exitCode int,
+) {
+	event := Event{
+		Action:    action,
+		Container: containerName,
+		App:       ctr.config.owner(),
+		ExitCode:  exitCode,
+		Time:      time.Now(),
+	}
+	for watcher := range m.watchers {
+		select {
+		case watcher <- event:
+		default:
+		}
+	}
+}
+
+// Returns the config the container was created with
+func (m *MemoryManager) ContainerConfig(containerName string) (
+	config Config, ok bool,
+) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ctr, ok := m.containers[containerName]
+	if !ok {
+		return Config{}, false
+	}
+	return ctr.config, true
+}
+
+// Sets health reported for the container, as if its healthcheck ran
+func (m *MemoryManager) SetHealth(containerName string, health string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ctr, ok := m.containers[containerName]
+	if !ok {
+		return ContainerDoesntExistErr
+	}
+	ctr.health = health
+	return nil
+}
+
+// Sets times the container was restarted by its restart policy, as if the
+// runtime restarted it after crashes
+func (m *MemoryManager) SetRestartCount(containerName string, restarts int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ctr, ok := m.containers[containerName]
+	if !ok {
+		return ContainerDoesntExistErr
+	}
+	ctr.restarts = restarts
+	return nil
+}
+
+// Returns labels of the volume and whether it exists
+func (m *MemoryManager) VolumeLabels(volumeName string) (
+	labels map[string]string, ok bool,
+) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	labels, ok = m.volumes[volumeName]
+	return maps.Clone(labels), ok
+}
+
+// Returns current state of the container. Empty when it does not exist
+func (m *MemoryManager) ContainerState(containerName string) string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ctr, ok := m.containers[containerName]
+	if !ok {
+		return ""
+	}
+	return ctr.state
+}
+
+// Sets the image ID that the next pull of imageName resolves to, emulating a
+// new image being pushed to the registry
+func (m *MemoryManager) SetRemoteImage(imageName string, imageID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.remote[imageName] = imageID
+}
+
+// Pulls instantly, only the final progress update is reported
+func (m *MemoryManager) PullImage(
+	ctx context.Context,
+	imageName string,
+	options PullOptions,
+) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	m.pullImage(imageName)
+	m.mu.Unlock()
+
+	options.report(PullProgress{Done: true})
+	return nil
+}
+
+func (m *MemoryManager) pullImage(imageName string) string {
+	imageID, ok := m.remote[imageName]
+	if !ok {
+		imageID = "sha256:" + imageName
+	}
+	m.images[imageName] = imageID
+	return imageID
+}
+
+func (m *MemoryManager) RemoveImage(ctx context.Context, imageName string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.images[imageName]; !ok {
+		return fmt.Errorf("image %q not known", imageName)
+	}
+	for name, ctr := range m.containers {
+		if ctr.image == imageName {
+			return fmt.Errorf("image %q is in use by container %q", imageName, name)
+		}
+	}
+
+	delete(m.images, imageName)
+	return nil
+}
+
+func (m *MemoryManager) ImageExists(
+	ctx context.Context,
+	imageName string,
+) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, ok := m.images[imageName]
+	return ok, nil
+}
+
+// Images of the manager take no space, Size is always zero
+func (m *MemoryManager) InspectImage(ctx context.Context, imageName string) (
+	details ImageDetails, err error,
+) {
+	if err := ctx.Err(); err != nil {
+		return details, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	imageID, ok := m.images[imageName]
+	if !ok {
+		return details, ImageDoesntExistErr
+	}
+	return ImageDetails{ID: imageID, Digest: imageID}, nil
+}
+
+// Lists running containers only, same as the runtime implementations
+func (m *MemoryManager) ListContainers(ctx context.Context) (
+	containerNames []string, err error,
+) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for name, ctr := range m.containers {
+		if ctr.state == MemoryStateRunning {
+			containerNames = append(containerNames, name)
+		}
+	}
+	slices.Sort(containerNames)
+
+	return containerNames, nil
+}
+
+// Lists every container, all containers of the manager are created by rocket
+func (m *MemoryManager) ListManagedContainers(ctx context.Context) (
+	managed []ManagedContainer, err error,
+) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for name, ctr := range m.containers {
+		managed = append(managed, ManagedContainer{
+			Name:   name,
+			State:  ctr.state,
+			Labels: containerLabels(ctr.config),
+		})
+	}
+	slices.SortFunc(managed, func(a, b ManagedContainer) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return managed, nil
+}
+
+func (m *MemoryManager) CreateContainer(ctx context.Context, options Config) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.containers[options.ContainerName]; exists {
+		return nil
+	}
+
+	image := options.Image()
+
+	imageID, ok := m.images[image]
+	if !ok {
+		return fmt.Errorf(
+			"create container %q failed: image %q not known",
+			options.ContainerName, image,
+		)
+	}
+
+	if err := options.Egress.validate(); err != nil {
+		return err
+	}
+	for _, network := range options.Networks() {
+		if !m.networks[network] {
+			return fmt.Errorf(
+				"create container %q failed: network %q not found",
+				options.ContainerName, network,
+			)
+		}
+	}
+
+	if _, ok := m.pods[options.Pod]; options.Pod != "" && !ok {
+		return fmt.Errorf(
+			"create container %q failed: pod %q not found",
+			options.ContainerName, options.Pod,
+		)
+	}
+
+	if _, err := options.Resources.linuxResources(); err != nil {
+		return err
+	}
+	if _, err := options.healthConfig(); err != nil {
+		return err
+	}
+	if err := options.Identity.validate(); err != nil {
+		return err
+	}
+	if err := options.Lifecycle.validate(); err != nil {
+		return err
+	}
+
+	for _, mount := range options.MountDirs {
+		if err := mount.validate(); err != nil {
+			return err
+		}
+		if mount.kind() != MountVolume {
+			continue
+		}
+		if _, exists := m.volumes[mount.Source]; !exists {
+			m.volumes[mount.Source] = ownerLabels(options)
+		}
+	}
+
+	m.containers[options.ContainerName] = &memoryContainer{
+		config:  options,
+		image:   image,
+		imageID: imageID,
+		state:   MemoryStateCreated,
+	}
+	return nil
+}
+
+func (m *MemoryManager) RemoveContainer(
+	ctx context.Context,
+	containerName string,
+	force bool,
+) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ctr, ok := m.containers[containerName]
+	if !ok {
+		return ContainerDoesntExistErr
+	}
+	if ctr.state == MemoryStateRunning && !force {
+		return fmt.Errorf("container %q is running", containerName)
+	}
+
+	delete(m.containers, containerName)
+	m.emit(EventRemove, containerName, ctr, 0)
+	return nil
+}
+
+func (m *MemoryManager) Events(ctx context.Context, handle func(Event)) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	watcher := make(chan Event, 64)
+	m.mu.Lock()
+	m.watchers[watcher] = true
+	m.mu.Unlock()
+	defer func() {
+		m.mu.Lock()
+		delete(m.watchers, watcher)
+		m.mu.Unlock()
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case event := <-watcher:
+			handle(event)
+		}
+	}
+}
+
+// Running containers use no resources, memory limit comes from their config
+func (m *MemoryManager) Stats(
+	ctx context.Context,
+	containerNames []string,
+) ([]ContainerStats, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats := []ContainerStats{}
+	for _, containerName := range containerNames {
+		ctr, ok := m.containers[containerName]
+		if !ok {
+			return nil, ContainerDoesntExistErr
+		}
+		if ctr.state != MemoryStateRunning {
+			return nil, fmt.Errorf("container %q is not running", containerName)
+		}
+
+		sample := ContainerStats{Name: containerName, PIDs: 1, Read: time.Now()}
+		if ctr.config.Resources.Memory != "" {
+			limit, err := parseSize(ctr.config.Resources.Memory)
+			if err == nil && limit > 0 {
+				sample.MemoryLimit = uint64(limit)
+			}
+		}
+		stats = append(stats, sample)
+	}
+	return stats, nil
+}
+
+func (m *MemoryManager) ContainerExists(
+	ctx context.Context,
+	containerName string,
+) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, ok := m.containers[containerName]
+	return ok, nil
+}
+
+func (m *MemoryManager) InspectContainer(
+	ctx context.Context,
+	containerName string,
+) (
+	status ContainerStatus, err error,
+) {
+	if err := ctx.Err(); err != nil {
+		return status, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ctr, ok := m.containers[containerName]
+	if !ok {
+		return status, ContainerDoesntExistErr
+	}
+
+	status = ContainerStatus{
+		Name:         containerName,
+		State:        ctr.state,
+		StartedAt:    ctr.startedAt,
+		Health:       ctr.health,
+		ImageDigest:  ctr.imageID,
+		RestartCount: ctr.restarts,
+		Ports:        map[int]int{},
+	}
+	for hostPort, containerPort := range ctr.config.BindPorts {
+		status.Ports[hostPort] = containerPort
+	}
+	if networks := ctr.config.Networks(); len(networks) > 0 {
+		status.Networks = slices.Sorted(slices.Values(networks))
+	}
+
+	return status, nil
+}
+
+// Appends a line to logs of the container, as if the app printed it
+func (m *MemoryManager) AppendLog(containerName string, line string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ctr, ok := m.containers[containerName]
+	if !ok {
+		return ContainerDoesntExistErr
+	}
+	ctr.logs = append(ctr.logs, line)
+	return nil
+}
+
+// Writes logs recorded with AppendLog. Following is not supported, the
+// recorded logs are returned right away
+func (m *MemoryManager) Logs(
+	ctx context.Context,
+	containerName string,
+	options LogOptions,
+	stdout io.Writer,
+	stderr io.Writer,
+) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ctr, ok := m.containers[containerName]
+	if !ok {
+		return ContainerDoesntExistErr
+	}
+
+	lines := ctr.logs
+	if options.Tail >= 0 && options.Tail < len(lines) {
+		lines = lines[len(lines)-options.Tail:]
+	}
+	for _, line := range lines {
+		if _, err := io.WriteString(stdout, line+"\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Returns commands executed in the container, in order of execution
+func (m *MemoryManager) ExecHistory(containerName string) [][]string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ctr, ok := m.containers[containerName]
+	if !ok {
+		return nil
+	}
+	return slices.Clone(ctr.execs)
+}
+
+// Records the command instead of running it. Commands always succeed
+func (m *MemoryManager) Exec(
+	ctx context.Context,
+	containerName string,
+	options ExecOptions,
+) (
+	exitCode int, err error,
+) {
+	if err := ctx.Err(); err != nil {
+		return -1, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ctr, ok := m.containers[containerName]
+	if !ok {
+		return -1, ContainerDoesntExistErr
+	}
+	if ctr.state != MemoryStateRunning {
+		return -1, fmt.Errorf("container %q is not running", containerName)
+	}
+
+	ctr.execs = append(ctr.execs, slices.Clone(options.Cmd))
+	return 0, nil
+}
+
+func (m *MemoryManager) StartService(
+	ctx context.Context,
+	containerName string,
+) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ctr, ok := m.containers[containerName]
+	if !ok {
+		return ContainerDoesntExistErr
+	}
+
+	if ctr.state != MemoryStateRunning {
+		ctr.state = MemoryStateRunning
+		ctr.startedAt = time.Now()
+		m.emit(EventStart, containerName, ctr, 0)
+	}
+	return nil
+}
+
+func (m *MemoryManager) PauseService(
+	ctx context.Context,
+	containerName string,
+) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ctr, ok := m.containers[containerName]
+	if !ok {
+		return ContainerDoesntExistErr
+	}
+	if ctr.state == MemoryStateRunning {
+		ctr.state = MemoryStatePaused
+	}
+	return nil
+}
+
+func (m *MemoryManager) UnpauseService(
+	ctx context.Context,
+	containerName string,
+) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ctr, ok := m.containers[containerName]
+	if !ok {
+		return ContainerDoesntExistErr
+	}
+	if ctr.state != MemoryStatePaused {
+		return fmt.Errorf("container %q is not paused", containerName)
+	}
+	ctr.state = MemoryStateRunning
+	return nil
+}
+
+func (m *MemoryManager) StopService(
+	ctx context.Context,
+	containerName string,
+) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ctr, ok := m.containers[containerName]
+	if !ok {
+		return ContainerDoesntExistErr
+	}
+	if ctr.state == MemoryStateRunning || ctr.state == MemoryStatePaused {
+		ctr.state = MemoryStateExited
+		m.emit(EventStop, containerName, ctr, 0)
+		m.emit(EventDie, containerName, ctr, 0)
+	}
+	return nil
+}
+
+func (m *MemoryManager) UpdateService(ctx context.Context, options Config) (
+	updated bool, err error,
+) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	m.mu.Lock()
+	ctr, ok := m.containers[options.ContainerName]
+	if !ok {
+		m.mu.Unlock()
+		return false, ContainerDoesntExistErr
+	}
+	pulledID := m.pullImage(options.Image())
+	currentID := ctr.imageID
+	wasRunning := ctr.state == MemoryStateRunning
+	m.mu.Unlock()
+
+	if pulledID == currentID {
+		return false, nil
+	}
+
+	if err = recreateService(ctx, m, options, wasRunning); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (m *MemoryManager) CreatePod(ctx context.Context, options Config) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	podName := options.PodName()
+	if _, exists := m.pods[podName]; exists {
+		return fmt.Errorf("pod %q already exists", podName)
+	}
+	if err := options.Egress.validate(); err != nil {
+		return err
+	}
+	for _, network := range options.Networks() {
+		if !m.networks[network] {
+			return fmt.Errorf("network %q not found", network)
+		}
+	}
+
+	m.pods[podName] = options
+	return nil
+}
+
+// Moves every container of the pod to the state
+func (m *MemoryManager) setPodState(podName string, state string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.pods[podName]; !ok {
+		return fmt.Errorf("pod %q not found", podName)
+	}
+
+	for name, ctr := range m.containers {
+		if ctr.config.Pod != podName || ctr.state == state {
+			continue
+		}
+		if state == MemoryStateExited && ctr.state == MemoryStateCreated {
+			continue
+		}
+		ctr.state = state
+		if state == MemoryStateRunning {
+			ctr.startedAt = time.Now()
+			m.emit(EventStart, name, ctr, 0)
+		} else {
+			m.emit(EventStop, name, ctr, 0)
+			m.emit(EventDie, name, ctr, 0)
+		}
+	}
+	return nil
+}
+
+func (m *MemoryManager) StartPod(ctx context.Context, podName string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return m.setPodState(podName, MemoryStateRunning)
+}
+
+func (m *MemoryManager) StopPod(ctx context.Context, podName string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return m.setPodState(podName, MemoryStateExited)
+}
+
+func (m *MemoryManager) RemovePod(
+	ctx context.Context,
+	podName string,
+	force bool,
+) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.pods[podName]; !ok {
+		return fmt.Errorf("pod %q not found", podName)
+	}
+
+	for name, ctr := range m.containers {
+		if ctr.config.Pod != podName {
+			continue
+		}
+		if ctr.state == MemoryStateRunning && !force {
+			return fmt.Errorf("pod %q has running container %q", podName, name)
+		}
+	}
+	for name, ctr := range m.containers {
+		if ctr.config.Pod == podName {
+			delete(m.containers, name)
+			m.emit(EventRemove, name, ctr, 0)
+		}
+	}
+
+	delete(m.pods, podName)
+	return nil
+}
+
+func (m *MemoryManager) PodExists(
+	ctx context.Context,
+	podName string,
+) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, ok := m.pods[podName]
+	return ok, nil
+}
+
+func (m *MemoryManager) ListManagedPods(ctx context.Context) (
+	managed []ManagedPod, err error,
+) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for name, options := range m.pods {
+		managed = append(managed, ManagedPod{
+			Name:   name,
+			Labels: podLabels(options),
+		})
+	}
+	slices.SortFunc(managed, func(a, b ManagedPod) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return managed, nil
+}
+
+func (m *MemoryManager) ListManagedVolumes(ctx context.Context) (
+	managed []ManagedVolume, err error,
+) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for name, labels := range m.volumes {
+		if labels[LabelManaged] != "true" {
+			continue
+		}
+		managed = append(managed, ManagedVolume{
+			Name:   name,
+			Labels: maps.Clone(labels),
+		})
+	}
+	slices.SortFunc(managed, func(a, b ManagedVolume) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return managed, nil
+}
+
+// Volumes mounted by a container are only removed when forced, which also
+// removes the containers
+func (m *MemoryManager) RemoveVolume(
+	ctx context.Context,
+	volumeName string,
+	force bool,
+) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.volumes[volumeName]; !ok {
+		return fmt.Errorf("volume %q not found", volumeName)
+	}
+
+	for name, ctr := range m.containers {
+		for _, mount := range ctr.config.MountDirs {
+			if mount.kind() != MountVolume || mount.Source != volumeName {
+				continue
+			}
+			if !force {
+				return fmt.Errorf("volume %q is in use by container %q", volumeName, name)
+			}
+			delete(m.containers, name)
+			m.emit(EventRemove, name, ctr, 0)
+			break
+		}
+	}
+
+	delete(m.volumes, volumeName)
+	delete(m.volumeData, volumeName)
+	return nil
+}
+
+// Writes the archive last imported into the volume, an empty archive if
+// there was none
+func (m *MemoryManager) ExportVolume(
+	ctx context.Context,
+	volumeName string,
+	contents io.Writer,
+) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.volumes[volumeName]; !ok {
+		return VolumeDoesntExistErr
+	}
+	data, ok := m.volumeData[volumeName]
+	if !ok {
+		return tar.NewWriter(contents).Close()
+	}
+	_, err := contents.Write(data)
+	return err
+}
+
+// Keeps the archive as the contents of the volume
+func (m *MemoryManager) ImportVolume(
+	ctx context.Context,
+	volumeName string,
+	contents io.Reader,
+) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	data, err := io.ReadAll(contents)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.volumes[volumeName]; !ok {
+		return VolumeDoesntExistErr
+	}
+	m.volumeData[volumeName] = data
+	return nil
+}
+
+func (m *MemoryManager) ListNetworks(ctx context.Context) (
+	networks []string, err error,
+) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for name := range m.networks {
+		networks = append(networks, name)
+	}
+	slices.Sort(networks)
+
+	return networks, nil
+}
+
+func (m *MemoryManager) CreateNetwork(
+	ctx context.Context,
+	networkName string,
+	internal bool,
+) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.networks[networkName] {
+		return fmt.Errorf("network %q already exists", networkName)
+	}
+	m.networks[networkName] = true
+	// every network gets a subnet of its own, like runtimes assign them
+	m.subnets[networkName] = fmt.Sprintf("10.89.%d.0/24", len(m.subnets)+1)
+	return nil
+}
+
+func (m *MemoryManager) NetworkExists(
+	ctx context.Context,
+	networkName string,
+) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.networks[networkName], nil
+}
+
+func (m *MemoryManager) NetworkSubnets(
+	ctx context.Context,
+	networkName string,
+) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.networks[networkName] {
+		return nil, fmt.Errorf("network %q not found", networkName)
+	}
+	return []string{m.subnets[networkName]}, nil
+}
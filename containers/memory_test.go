@@ -0,0 +1,14 @@
+package containers_test
+
+import (
+	"testing"
+
+	"ayayushsharma/rocket/containers"
+	"ayayushsharma/rocket/containers/containertest"
+)
+
+func TestMemoryManager(t *testing.T) {
+	containertest.Run(t, func(t *testing.T) containers.ContainerManager {
+		return containers.NewMemoryManager()
+	})
+}
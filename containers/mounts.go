@@ -0,0 +1,189 @@
+package containers
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+)
+
+// Kinds of mounts that can be attached to a container
+const (
+	MountBind   = "bind"
+	MountVolume = "volume"
+	MountTmpfs  = "tmpfs"
+)
+
+// SELinux relabelling of mounted content
+const (
+	// content is shared between containers ("z")
+	RelabelShared = "shared"
+	// content is private to the container ("Z")
+	RelabelPrivate = "private"
+)
+
+type Mount struct {
+	// one of "bind", "volume" or "tmpfs". Defaults to "bind"
+	Type string
+
+	// host directory for binds, name of the volume for volumes.
+	// Not used for tmpfs
+	Source string
+
+	// path inside the container
+	Destination string
+
+	// mounts are read-write unless set
+	ReadOnly bool `json:",omitempty"`
+
+	// SELinux relabelling, "shared" or "private". Not relabelled if empty
+	Relabel string `json:",omitempty"`
+
+	// size of tmpfs mounts e.g. "64m". Runtime default if empty
+	Size string `json:",omitempty"`
+
+	// recursively chown the source to the user of the container before it
+	// starts. Podman only, not for tmpfs
+	Chown bool `json:",omitempty"`
+
+	// mount with ids mapped from the user namespace of the container, so
+	// files keep their owners on the host. Podman only, not for tmpfs
+	IDMap bool `json:",omitempty"`
+}
+
+// Mounts of a container.
+//
+// Older workspaces stored mounts as a map of host dir to container dir which
+// were always mounted read-only. Those are still accepted while reading.
+type MountList []Mount
+
+func (m *MountList) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+
+	var legacy map[string]string
+	if err := json.Unmarshal(data, &legacy); err == nil {
+		*m = legacyMounts(legacy)
+		return nil
+	}
+
+	var mounts []Mount
+	if err := json.Unmarshal(data, &mounts); err != nil {
+		return err
+	}
+	*m = mounts
+	return nil
+}
+
+// Converts map of host dir to container dir into read-only binds
+func legacyMounts(mountDirs map[string]string) MountList {
+	hostDirs := []string{}
+	for hostDir := range mountDirs {
+		hostDirs = append(hostDirs, hostDir)
+	}
+	slices.Sort(hostDirs)
+
+	mounts := MountList{}
+	for _, hostDir := range hostDirs {
+		mounts = append(mounts, Mount{
+			Type:        MountBind,
+			Source:      hostDir,
+			Destination: mountDirs[hostDir],
+			ReadOnly:    true,
+		})
+	}
+	return mounts
+}
+
+func (m Mount) kind() string {
+	if m.Type == "" {
+		return MountBind
+	}
+	return m.Type
+}
+
+func (m Mount) validate() error {
+	if m.Destination == "" {
+		return fmt.Errorf("mount of %q has no destination", m.Source)
+	}
+
+	switch m.kind() {
+	case MountBind, MountVolume:
+		if m.Source == "" {
+			return fmt.Errorf("%s mount on %q has no source", m.kind(), m.Destination)
+		}
+	case MountTmpfs:
+	default:
+		return fmt.Errorf("unknown mount type %q on %q", m.Type, m.Destination)
+	}
+
+	if m.kind() == MountTmpfs && (m.Chown || m.IDMap) {
+		return fmt.Errorf("ownership options on tmpfs mount %q", m.Destination)
+	}
+
+	switch m.Relabel {
+	case "", RelabelShared, RelabelPrivate:
+	default:
+		return fmt.Errorf("unknown relabel option %q on %q", m.Relabel, m.Destination)
+	}
+
+	return nil
+}
+
+// Named volumes and host directories of read-write binds the app stores data
+// in, stack members included. Each is listed once
+func (c Config) DataMounts() (volumes []string, binds []string) {
+	members := []Config{c}
+	if c.IsStack() {
+		members = c.StackContainers()
+	}
+
+	for _, member := range members {
+		for _, mount := range member.MountDirs {
+			switch {
+			case mount.kind() == MountVolume:
+				if !slices.Contains(volumes, mount.Source) {
+					volumes = append(volumes, mount.Source)
+				}
+			case mount.kind() == MountBind && !mount.ReadOnly:
+				if !slices.Contains(binds, mount.Source) {
+					binds = append(binds, mount.Source)
+				}
+			}
+		}
+	}
+	return volumes, binds
+}
+
+// Mount options understood by both podman and docker
+func (m Mount) options() (options []string) {
+	if m.ReadOnly {
+		options = append(options, "ro")
+	} else {
+		options = append(options, "rw")
+	}
+
+	switch m.Relabel {
+	case RelabelShared:
+		options = append(options, "z")
+	case RelabelPrivate:
+		options = append(options, "Z")
+	}
+
+	if m.kind() == MountTmpfs && m.Size != "" {
+		options = append(options, "size="+m.Size)
+	}
+
+	return options
+}
+
+// Ownership options only podman understands
+func (m Mount) ownershipOptions() (options []string) {
+	if m.Chown {
+		options = append(options, "U")
+	}
+	if m.IDMap {
+		options = append(options, "idmap")
+	}
+	return options
+}
@@ -0,0 +1,68 @@
+package containers
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestMountListLegacyMap(t *testing.T) {
+	var mounts MountList
+	legacy := `{"/srv/web": "/usr/share/nginx/html", "/etc/web": "/etc/nginx"}`
+	if err := json.Unmarshal([]byte(legacy), &mounts); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+
+	// sorted by host dir and always read-only
+	want := MountList{
+		{Type: MountBind, Source: "/etc/web", Destination: "/etc/nginx", ReadOnly: true},
+		{Type: MountBind, Source: "/srv/web", Destination: "/usr/share/nginx/html", ReadOnly: true},
+	}
+	if !reflect.DeepEqual(mounts, want) {
+		t.Errorf("mounts = %+v, want %+v", mounts, want)
+	}
+}
+
+func TestMountListRoundTrip(t *testing.T) {
+	mounts := MountList{
+		{Type: MountBind, Source: "/srv/web", Destination: "/data", Relabel: RelabelPrivate, Chown: true},
+		{Type: MountVolume, Source: "web-db", Destination: "/var/lib/db", IDMap: true},
+		{Type: MountTmpfs, Destination: "/tmp", Size: "64m"},
+		{Source: "/etc/web", Destination: "/etc/web", ReadOnly: true},
+	}
+
+	data, err := json.Marshal(mounts)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	var decoded MountList
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal(%s): %v", data, err)
+	}
+	if !reflect.DeepEqual(decoded, mounts) {
+		t.Errorf("round trip = %+v, want %+v", decoded, mounts)
+	}
+}
+
+func TestMountListInConfig(t *testing.T) {
+	var legacy Config
+	if err := json.Unmarshal([]byte(`{"MountDirs": {"/srv": "/data"}}`), &legacy); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if len(legacy.MountDirs) != 1 || !legacy.MountDirs[0].ReadOnly {
+		t.Errorf("legacy MountDirs = %+v, want one read-only bind", legacy.MountDirs)
+	}
+
+	var unset Config
+	if err := json.Unmarshal([]byte(`{"MountDirs": null}`), &unset); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if unset.MountDirs != nil {
+		t.Errorf("null MountDirs = %+v, want none", unset.MountDirs)
+	}
+
+	var invalid Config
+	if err := json.Unmarshal([]byte(`{"MountDirs": "/srv:/data"}`), &invalid); err == nil {
+		t.Errorf("MountDirs of a string accepted as %+v", invalid.MountDirs)
+	}
+}
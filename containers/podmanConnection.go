@@ -0,0 +1,181 @@
+package containers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"ayayushsharma/rocket/constants"
+)
+
+// Socket of the system wide podman service
+const rootfulPodmanSocket = "/run/podman/podman.sock"
+
+type connectionFileV2 struct {
+	Connection struct {
+		Default     string                       `json:"Default"`
+		Connections map[string]connectionEntryV2 `json:"Connections"`
+	} `json:"Connection"`
+	// "Farm" may exist, we ignore it.
+}
+
+type connectionEntryV2 struct {
+	URI      string `json:"URI"`
+	Identity string `json:"Identity"`
+	// Some builds add flags like IsMachine, TLS*, etc.
+}
+
+// Legacy schema (array of objects produced by `podman system connection list --format=json`)
+type connectionEntryV1 struct {
+	Name      string `json:"Name"`
+	URI       string `json:"URI"`
+	Identity  string `json:"Identity"`
+	Default   bool   `json:"Default"`
+	ReadWrite bool   `json:"ReadWrite"`
+}
+
+var noConnectionsFileErr = errors.New("podman connections file not found")
+
+// Connections files without a default connection, e.g. after the last
+// connection was removed, leave the choice to local socket discovery
+var noDefaultConnectionErr = errors.New("podman connections file has no default connection")
+
+func connectionsPath() (path string, err error) {
+	if p := os.Getenv("PODMAN_CONNECTIONS_CONF"); p != "" {
+		return p, nil
+	}
+
+	userConfigPath := constants.UserConfigDir
+	path = filepath.Join(
+		userConfigPath, "containers", "podman-connections.json",
+	)
+	return
+}
+
+// loadConnection reads the connections file and returns (uri, identity) of
+// the named connection. Default connection is used when name is empty
+func loadConnection(name string) (string, string, error) {
+	path, err := connectionsPath()
+	if err != nil {
+		return "", "", err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", "", noConnectionsFileErr
+		}
+		return "", "", fmt.Errorf("open %s: %w", path, err)
+	}
+
+	// Try newer V2 schema (object with Connection.Default + map)
+	var v2 connectionFileV2
+	if err2 := json.Unmarshal(data, &v2); err2 == nil {
+		if name == "" {
+			name = v2.Connection.Default
+		}
+		if name == "" {
+			return "", "", noDefaultConnectionErr
+		}
+		entry, ok := v2.Connection.Connections[name]
+		if !ok {
+			return "", "", fmt.Errorf("connection %q not found in Connections", name)
+		}
+		if entry.URI == "" {
+			return "", "", fmt.Errorf("connection %q has empty URI", name)
+		}
+		return entry.URI, entry.Identity, nil
+	}
+
+	// Fallback: legacy V1 schema (array)
+	var v1 []connectionEntryV1
+	if err1 := json.Unmarshal(data, &v1); err1 == nil {
+		for _, c := range v1 {
+			if (name == "" && c.Default) || (name != "" && c.Name == name) {
+				if c.URI == "" {
+					return "", "", fmt.Errorf("connection %q (v1) has empty URI", c.Name)
+				}
+				return c.URI, c.Identity, nil
+			}
+		}
+		if name != "" {
+			return "", "", fmt.Errorf("connection %q not found in legacy array", name)
+		}
+		return "", "", noDefaultConnectionErr
+	}
+
+	return "", "", errors.New("unrecognized podman connections schema")
+}
+
+// Socket of the rootless podman service of current user
+func rootlessPodmanSocket() string {
+	runtimeDir := os.Getenv("XDG_RUNTIME_DIR")
+	if runtimeDir == "" {
+		runtimeDir = filepath.Join("/run/user", strconv.Itoa(os.Getuid()))
+	}
+	return filepath.Join(runtimeDir, "podman", "podman.sock")
+}
+
+// Key podman machine creates for reaching the VM over ssh
+func defaultContainerSSHKey() (sshKeyPath string) {
+	homeDir := constants.UserHomeDir
+	return filepath.Join(
+		homeDir, "/.local/share/containers/podman/machine/machine",
+	)
+}
+
+// Resolves (uri, identity) of the podman service to connect to. In order:
+//   - named connection from podman-connections.json, when a name is given
+//   - CONTAINER_HOST with CONTAINER_SSHKEY, same as the podman CLI
+//   - default connection from podman-connections.json
+//   - rootless socket under $XDG_RUNTIME_DIR
+//   - rootful socket under /run/podman
+func resolvePodmanConnection(name string) (uri string, identity string, err error) {
+	if name != "" {
+		uri, identity, err = loadConnection(name)
+		if err != nil {
+			return "", "", err
+		}
+		slog.Debug("Using named podman connection", "name", name)
+		return uri, sshIdentity(uri, identity), nil
+	}
+
+	if host := os.Getenv("CONTAINER_HOST"); host != "" {
+		slog.Debug("Using podman connection from CONTAINER_HOST")
+		return host, sshIdentity(host, os.Getenv("CONTAINER_SSHKEY")), nil
+	}
+
+	uri, identity, err = loadConnection("")
+	if err == nil {
+		slog.Debug("Using default podman connection")
+		return uri, sshIdentity(uri, identity), nil
+	}
+	if !errors.Is(err, noConnectionsFileErr) && !errors.Is(err, noDefaultConnectionErr) {
+		return "", "", err
+	}
+
+	for _, socket := range []string{rootlessPodmanSocket(), rootfulPodmanSocket} {
+		if _, statErr := os.Stat(socket); statErr == nil {
+			slog.Debug("Using local podman socket", "path", socket)
+			return "unix://" + socket, "", nil
+		}
+	}
+
+	return "", "", fmt.Errorf(
+		"no podman connection configured and no socket found at %s or %s",
+		rootlessPodmanSocket(),
+		rootfulPodmanSocket,
+	)
+}
+
+// ssh connections without an identity fall back to the podman machine key
+func sshIdentity(uri string, identity string) string {
+	if identity != "" || !strings.HasPrefix(uri, "ssh://") {
+		return identity
+	}
+	return defaultContainerSSHKey()
+}
@@ -0,0 +1,73 @@
+package containers
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Points connection discovery at a connections file with the contents and a
+// rootless socket under a temporary runtime dir. Returns the socket URI
+func useConnectionsFile(t *testing.T, contents string) (socketURI string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	connections := filepath.Join(dir, "podman-connections.json")
+	if err := os.WriteFile(connections, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PODMAN_CONNECTIONS_CONF", connections)
+	t.Setenv("CONTAINER_HOST", "")
+	t.Setenv("XDG_RUNTIME_DIR", dir)
+
+	socket := rootlessPodmanSocket()
+	if err := os.MkdirAll(filepath.Dir(socket), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(socket, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return "unix://" + socket
+}
+
+func TestResolvePodmanConnectionWithoutDefault(t *testing.T) {
+	files := map[string]string{
+		"empty default":  `{"Connection": {"Default": "", "Connections": {"remote": {"URI": "ssh://core@host/run/podman.sock"}}}}`,
+		"no connections": `{"Connection": {"Default": ""}}`,
+		"empty object":   `{}`,
+		"empty legacy":   `[]`,
+		"legacy":         `[{"Name": "remote", "URI": "ssh://core@host/run/podman.sock"}]`,
+	}
+	for name, contents := range files {
+		t.Run(name, func(t *testing.T) {
+			socketURI := useConnectionsFile(t, contents)
+
+			uri, _, err := resolvePodmanConnection("")
+			if err != nil {
+				t.Fatalf("resolvePodmanConnection: %v", err)
+			}
+			if uri != socketURI {
+				t.Errorf("resolved %q, want local socket %q", uri, socketURI)
+			}
+		})
+	}
+}
+
+func TestResolvePodmanConnectionDefault(t *testing.T) {
+	useConnectionsFile(t, `{"Connection": {
+		"Default": "remote",
+		"Connections": {"remote": {"URI": "tcp://host:8080"}}
+	}}`)
+
+	uri, _, err := resolvePodmanConnection("")
+	if err != nil {
+		t.Fatalf("resolvePodmanConnection: %v", err)
+	}
+	if uri != "tcp://host:8080" {
+		t.Errorf("resolved %q, want the default connection", uri)
+	}
+
+	if _, _, err = resolvePodmanConnection("missing"); err == nil {
+		t.Error("unknown named connection resolved")
+	}
+}
@@ -1,167 +1,156 @@
 package containers
 
 import (
+	"bufio"
 	"context"
-	"encoding/json"
-	"errors"
 	"fmt"
+	"io"
 	"log/slog"
-	"os"
-	"path/filepath"
-	"strings"
+	"maps"
+	"slices"
+	"strconv"
+	"time"
 
+	"github.com/containers/podman/v6/pkg/api/handlers"
 	"github.com/containers/podman/v6/pkg/bindings"
 	"github.com/containers/podman/v6/pkg/bindings/containers"
 	"github.com/containers/podman/v6/pkg/bindings/images"
 	"github.com/containers/podman/v6/pkg/bindings/network"
+	"github.com/containers/podman/v6/pkg/bindings/pods"
+	"github.com/containers/podman/v6/pkg/bindings/system"
+	"github.com/containers/podman/v6/pkg/bindings/volumes"
+	"github.com/containers/podman/v6/pkg/domain/entities"
+	"github.com/containers/podman/v6/pkg/domain/entities/types"
 	"github.com/containers/podman/v6/pkg/specgen"
 	spec "github.com/opencontainers/runtime-spec/specs-go"
 	nettypes "go.podman.io/common/libnetwork/types"
-
-	"ayayushsharma/rocket/constants"
+	"go.podman.io/image/v5/manifest"
+	"go.podman.io/storage/pkg/idtools"
+	storagetypes "go.podman.io/storage/types"
 )
 
-type connectionFileV2 struct {
-	Connection struct {
-		Default     string                       `json:"Default"`
-		Connections map[string]connectionEntryV2 `json:"Connections"`
-	} `json:"Connection"`
-	// "Farm" may exist, we ignore it.
-}
-
-type connectionEntryV2 struct {
-	URI      string `json:"URI"`
-	Identity string `json:"Identity"`
-	// Some builds add flags like IsMachine, TLS*, etc.
-}
-
-// Legacy schema (array of objects produced by `podman system connection list --format=json`)
-type connectionEntryV1 struct {
-	Name      string `json:"Name"`
-	URI       string `json:"URI"`
-	Identity  string `json:"Identity"`
-	Default   bool   `json:"Default"`
-	ReadWrite bool   `json:"ReadWrite"`
-}
-
-func connectionsPath() (path string, err error) {
-	if p := os.Getenv("PODMAN_CONNECTIONS_CONF"); p != "" {
-		return p, nil
-	}
-
-	userConfigPath := constants.UserConfigDir
-	path = filepath.Join(
-		userConfigPath, "containers", "podman-connections.json",
-	)
-	return
-}
-
-// loadDefaultConnection reads the file and returns (uri, identity).
-func loadDefaultConnection() (string, string, error) {
-	path, err := connectionsPath()
-	if err != nil {
-		return "", "", err
-	}
-	data, err := os.ReadFile(path)
-	if err != nil {
-		return "", "", fmt.Errorf("open %s: %w", path, err)
-	}
-
-	// Try newer V2 schema (object with Connection.Default + map)
-	var v2 connectionFileV2
-	if err2 := json.Unmarshal(data, &v2); err2 == nil && v2.Connection.Connections != nil {
-		def := v2.Connection.Default
-		if def == "" {
-			return "", "", errors.New("connections file has empty Default")
-		}
-		entry, ok := v2.Connection.Connections[def]
-		if !ok {
-			return "", "", fmt.Errorf("default connection %q not found in Connections", def)
-		}
-		if entry.URI == "" {
-			return "", "", errors.New("default connection has empty URI")
-		}
-		return entry.URI, entry.Identity, nil
-	}
-
-	// Fallback: legacy V1 schema (array)
-	var v1 []connectionEntryV1
-	if err1 := json.Unmarshal(data, &v1); err1 == nil && len(v1) > 0 {
-		for _, c := range v1 {
-			if c.Default {
-				if c.URI == "" {
-					return "", "", errors.New("default connection (v1) has empty URI")
-				}
-				return c.URI, c.Identity, nil
-			}
-		}
-		return "", "", errors.New("no default connection in legacy array")
-	}
-
-	return "", "", errors.New("unrecognized podman connections schema")
-}
-
-func defaultSocketURI() (string, error) {
-	uri, _, err := loadDefaultConnection()
-	return uri, err
-}
-
-func defaultContainerSSHKey() (sshKeyPath string, err error) {
-	homeDir := constants.UserHomeDir
-	sshKeyPath = filepath.Join(
-		homeDir, "/.local/share/containers/podman/machine/machine",
-	)
-	return
-}
-
 type PodManContext struct {
+	// connection of podman bindings, requests bind it to their own context
 	context.Context
+	timeouts Timeouts
 }
 
-func connectPodman() (PodManContext, error) {
-	socketURI, err := defaultSocketURI()
+func connectPodman(options ConnectOptions) (PodManContext, error) {
+	socketURI, identity, err := resolvePodmanConnection(options.Connection)
 	if err != nil {
 		slog.Error("Couldn't connect to Podman", "error", err)
 		slog.Error("Check if Podman service is running on the machine")
-		return PodManContext{nil}, err
+		return PodManContext{}, err
 	}
 
 	slog.Debug("Socket URI found", "uri", socketURI)
-
-	sshkeyPath, err := defaultContainerSSHKey()
-	if err != nil {
-		return PodManContext{nil}, err
-	}
-	slog.Debug("Container SSH Key", "key", sshkeyPath)
+	slog.Debug("Container SSH Key", "key", identity)
 
 	conn, err := bindings.NewConnectionWithOptions(
 		context.Background(),
 		bindings.Options{
 			URI:      socketURI,
-			Identity: sshkeyPath,
+			Identity: identity,
 		},
 	)
 
 	if err != nil {
-		return PodManContext{nil}, err
+		return PodManContext{}, err
 	}
 
 	slog.Debug("Connected to podman")
-	return PodManContext{conn}, nil
+	return PodManContext{Context: conn, timeouts: options.Timeouts}, nil
 }
 
-func (conn PodManContext) PullImage(imageName string) error {
-	_, err := images.Pull(conn, imageName, nil)
-	if err != nil {
+// Podman bindings look the connection up from the context of every request.
+// Returned context carries the connection while cancellation and deadline
+// follow ctx, so callers can abort requests in flight
+func (conn PodManContext) bind(ctx context.Context) (
+	bound context.Context, release context.CancelFunc,
+) {
+	bound, cancel := context.WithCancel(conn.Context)
+	stop := context.AfterFunc(ctx, cancel)
+	release = func() {
+		stop()
+		cancel()
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		var cancelDeadline context.CancelFunc
+		bound, cancelDeadline = context.WithDeadline(bound, deadline)
+		release = func() {
+			cancelDeadline()
+			stop()
+			cancel()
+		}
+	}
+
+	return bound, release
+}
+
+func (conn PodManContext) PullImage(
+	ctx context.Context,
+	imageName string,
+	options PullOptions,
+) error {
+	ctx, cancel := withTimeout(ctx, conn.timeouts.Pull)
+	defer cancel()
+	ctx, release := conn.bind(ctx)
+	defer release()
+
+	if _, err := conn.pull(ctx, imageName, options); err != nil {
 		return err
 	}
 	slog.Debug("Pulled Podman image", "name", imageName)
 	return nil
 }
 
-func (conn PodManContext) RemoveImage(imageName string) error {
+// Pulls the image and returns its ID. Expects ctx bound to the connection
+func (conn PodManContext) pull(
+	ctx context.Context,
+	imageName string,
+	options PullOptions,
+) (imageID string, err error) {
+	pullOptions := new(images.PullOptions).WithQuiet(options.Progress == nil)
+
+	var writer *io.PipeWriter
+	var done chan struct{}
+	if options.Progress != nil {
+		writer, done = options.podmanProgressWriter()
+		pullOptions = pullOptions.WithProgressWriter(writer)
+	}
+
+	pulled, err := images.Pull(ctx, imageName, pullOptions)
+	if writer != nil {
+		_ = writer.Close()
+		<-done
+	}
+	if err != nil {
+		return "", err
+	}
+	if len(pulled) == 0 {
+		return "", fmt.Errorf("pull %s returned no image", imageName)
+	}
+
+	if options.Progress != nil {
+		var size int64
+		if report, err := images.GetImage(ctx, pulled[0], nil); err == nil {
+			size = report.Size
+		}
+		options.report(PullProgress{Done: true, Total: size})
+	}
+
+	return pulled[0], nil
+}
+
+func (conn PodManContext) RemoveImage(ctx context.Context, imageName string) (
+	err error,
+) {
+	ctx, release := conn.bind(ctx)
+	defer release()
+
 	imageList := []string{imageName}
-	report, errs := images.Remove(conn, imageList, nil)
+	report, errs := images.Remove(ctx, imageList, nil)
 	if errs != nil {
 		return errs[0]
 	}
@@ -169,15 +158,48 @@ func (conn PodManContext) RemoveImage(imageName string) error {
 	return nil
 }
 
-func (conn PodManContext) ImageExists(imageName string) (exists bool, err error) {
-	exists, err = images.Exists(conn, imageName, nil)
+func (conn PodManContext) ImageExists(ctx context.Context, imageName string) (
+	exists bool, err error,
+) {
+	ctx, release := conn.bind(ctx)
+	defer release()
+
+	exists, err = images.Exists(ctx, imageName, nil)
 	return
 }
 
-func (conn PodManContext) ListContainers() (
+func (conn PodManContext) InspectImage(ctx context.Context, imageName string) (
+	details ImageDetails, err error,
+) {
+	ctx, release := conn.bind(ctx)
+	defer release()
+
+	exists, err := images.Exists(ctx, imageName, nil)
+	if err != nil {
+		return
+	}
+	if !exists {
+		return details, ImageDoesntExistErr
+	}
+
+	report, err := images.GetImage(ctx, imageName, nil)
+	if err != nil {
+		return
+	}
+	return ImageDetails{
+		ID:     report.ID,
+		Digest: pulledDigest(imageName, report.RepoDigests, report.Digest.String()),
+		Size:   report.Size,
+	}, nil
+}
+
+func (conn PodManContext) ListContainers(ctx context.Context) (
 	containerNames []string, err error,
 ) {
-	containerList, err := containers.List(conn, nil)
+	ctx, release := conn.bind(ctx)
+	defer release()
+
+	containerList, err := containers.List(ctx, nil)
 	if err != nil {
 		return
 	}
@@ -189,66 +211,392 @@ func (conn PodManContext) ListContainers() (
 	return
 }
 
-func (conn PodManContext) ContainerExists(containerName string) (
-	exists bool, err error,
+// Lists containers created by rocket, running or not
+func (conn PodManContext) ListManagedContainers(ctx context.Context) (
+	managed []ManagedContainer, err error,
 ) {
-	exists, err = containers.Exists(conn, containerName, nil)
+	ctx, release := conn.bind(ctx)
+	defer release()
+
+	options := new(containers.ListOptions).
+		WithAll(true).
+		WithFilters(map[string][]string{"label": {managedFilter}})
+
+	containerList, err := containers.List(ctx, options)
+	if err != nil {
+		return
+	}
+
+	for _, cont := range containerList {
+		for _, name := range cont.Names {
+			managed = append(managed, ManagedContainer{
+				Name:   name,
+				State:  cont.State,
+				Labels: cont.Labels,
+			})
+		}
+	}
+
 	return
 }
 
-func (conn PodManContext) CreateContainer(options Config) (err error) {
-	image := options.ImageURL
+// Streams start, stop, die and remove events of managed containers to
+// handle until ctx is done
+func (conn PodManContext) Events(
+	ctx context.Context,
+	handle func(Event),
+) (err error) {
+	ctx, release := conn.bind(ctx)
+	defer release()
 
-	if strings.Trim(options.ImageVersion, " ") != "" {
-		image = options.ImageURL + ":" + strings.Trim(options.ImageVersion, " ")
+	options := new(system.EventsOptions).
+		WithStream(true).
+		WithFilters(map[string][]string{
+			"type":  {"container"},
+			"label": {managedFilter},
+		})
+
+	eventChan := make(chan types.Event)
+	cancelChan := make(chan bool)
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- system.Events(ctx, eventChan, cancelChan, options)
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			close(cancelChan)
+			// bindings block on delivering events read before the stream closed
+			go func() {
+				for range eventChan {
+				}
+			}()
+			return ctx.Err()
+		case err := <-errChan:
+			return err
+		case raw, ok := <-eventChan:
+			if !ok {
+				return <-errChan
+			}
+			event, ok := newEvent(
+				string(raw.Action), raw.Actor.Attributes, time.Unix(0, raw.TimeNano),
+			)
+			if ok {
+				handle(event)
+			}
+		}
+	}
+}
+
+// Resource usage of the running containers
+func (conn PodManContext) Stats(
+	ctx context.Context,
+	containerNames []string,
+) (stats []ContainerStats, err error) {
+	if len(containerNames) == 0 {
+		return nil, nil
+	}
+	ctx, release := conn.bind(ctx)
+	defer release()
+
+	reports, err := containers.Stats(
+		ctx, containerNames, new(containers.StatsOptions).WithStream(false),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	read := time.Now()
+	for report := range reports {
+		if report.Error != nil {
+			return nil, report.Error
+		}
+		for _, sample := range report.Stats {
+			stats = append(stats, ContainerStats{
+				Name:        sample.Name,
+				CPUPercent:  sample.CPU,
+				CPUTime:     time.Duration(sample.CPUNano),
+				MemoryUsage: sample.MemUsage,
+				MemoryLimit: sample.MemLimit,
+				NetInput:    sample.NetInput,
+				NetOutput:   sample.NetOutput,
+				BlockInput:  sample.BlockInput,
+				BlockOutput: sample.BlockOutput,
+				PIDs:        sample.PIDs,
+				Read:        read,
+			})
+		}
+	}
+	return stats, nil
+}
+
+func (conn PodManContext) ContainerExists(
+	ctx context.Context,
+	containerName string,
+) (exists bool, err error) {
+	ctx, release := conn.bind(ctx)
+	defer release()
+
+	exists, err = containers.Exists(ctx, containerName, nil)
+	return
+}
+
+func (conn PodManContext) InspectContainer(
+	ctx context.Context,
+	containerName string,
+) (status ContainerStatus, err error) {
+	ctx, release := conn.bind(ctx)
+	defer release()
+
+	exists, err := containers.Exists(ctx, containerName, nil)
+	if err != nil {
+		return
+	}
+	if !exists {
+		return status, ContainerDoesntExistErr
+	}
+
+	ctrData, err := containers.Inspect(ctx, containerName, nil)
+	if err != nil {
+		return
+	}
+
+	status = ContainerStatus{
+		Name:         containerName,
+		RestartCount: int(ctrData.RestartCount),
+		ImageDigest:  ctrData.ImageDigest,
+		Ports:        map[int]int{},
+	}
+	// reported like the digests images are pinned to
+	imageData, err := images.GetImage(ctx, ctrData.Image, nil)
+	if err == nil {
+		status.ImageDigest = pulledDigest(
+			ctrData.ImageName, imageData.RepoDigests, ctrData.ImageDigest,
+		)
+	}
+
+	if ctrData.State != nil {
+		status.State = ctrData.State.Status
+		status.ExitCode = int(ctrData.State.ExitCode)
+		status.StartedAt = ctrData.State.StartedAt
+		if ctrData.State.Health != nil {
+			status.Health = ctrData.State.Health.Status
+		}
+	}
+
+	if ctrData.NetworkSettings != nil {
+		status.Networks = slices.Sorted(maps.Keys(ctrData.NetworkSettings.Networks))
+	}
+
+	if ctrData.HostConfig != nil {
+		for containerPort, bindings := range ctrData.HostConfig.PortBindings {
+			ctrPort, err := parsePortKey(containerPort)
+			if err != nil {
+				continue
+			}
+			for _, binding := range bindings {
+				hostPort, err := strconv.Atoi(binding.HostPort)
+				if err != nil {
+					continue
+				}
+				status.Ports[hostPort] = ctrPort
+			}
+		}
+	}
+
+	return status, nil
+}
+
+// Writes logs of the container to stdout and stderr. Blocks till the logs
+// are exhausted, or till the container exits when following
+func (conn PodManContext) Logs(
+	ctx context.Context,
+	containerName string,
+	options LogOptions,
+	stdout io.Writer,
+	stderr io.Writer,
+) (err error) {
+	ctx, release := conn.bind(ctx)
+	defer release()
+
+	logOptions := new(containers.LogOptions).
+		WithFollow(options.Follow).
+		WithTail(options.tail()).
+		WithStdout(true).
+		WithStderr(true)
+	if since := options.since(); since != "" {
+		logOptions = logOptions.WithSince(since)
+	}
+
+	// bindings never close the channels, completion is signalled by return
+	stdoutChan := make(chan string)
+	stderrChan := make(chan string)
+	errChan := make(chan error, 1)
+
+	go func() {
+		errChan <- containers.Logs(
+			ctx, containerName, logOptions, stdoutChan, stderrChan,
+		)
+	}()
+
+	for {
+		select {
+		case line := <-stdoutChan:
+			_, _ = io.WriteString(stdout, line+"\n")
+		case line := <-stderrChan:
+			_, _ = io.WriteString(stderr, line+"\n")
+		case err = <-errChan:
+			return err
+		}
+	}
+}
+
+// Runs command in the container and returns exit code of the command
+func (conn PodManContext) Exec(
+	ctx context.Context,
+	containerName string,
+	options ExecOptions,
+) (exitCode int, err error) {
+	ctx, release := conn.bind(ctx)
+	defer release()
+
+	execConfig := new(handlers.ExecCreateConfig)
+	execConfig.Cmd = options.Cmd
+	execConfig.Tty = options.Tty
+	execConfig.AttachStdin = options.Interactive
+	execConfig.AttachStdout = true
+	execConfig.AttachStderr = true
+
+	sessionID, err := containers.ExecCreate(ctx, containerName, execConfig)
+	if err != nil {
+		return -1, fmt.Errorf("create exec session in %q: %w", containerName, err)
+	}
+
+	attachOptions := new(containers.ExecStartAndAttachOptions).
+		WithOutputStream(options.Stdout).
+		WithErrorStream(options.Stderr).
+		WithAttachOutput(true).
+		WithAttachError(true)
+	if options.Interactive && options.Stdin != nil {
+		attachOptions = attachOptions.
+			WithInputStream(*bufio.NewReader(options.Stdin)).
+			WithAttachInput(true)
+	}
+
+	err = containers.ExecStartAndAttach(ctx, sessionID, attachOptions)
+	if err != nil {
+		return -1, err
+	}
+
+	session, err := containers.ExecInspect(ctx, sessionID, nil)
+	if err != nil {
+		return -1, err
 	}
 
+	slog.Debug("Exec session finished", "session", sessionID, "exit_code", session.ExitCode)
+	return session.ExitCode, nil
+}
+
+func (conn PodManContext) CreateContainer(ctx context.Context, options Config) (
+	err error,
+) {
+	ctx, cancel := withTimeout(ctx, conn.timeouts.Create)
+	defer cancel()
+	ctx, release := conn.bind(ctx)
+	defer release()
+
+	image := options.Image()
+
 	s := specgen.NewSpecGenerator(image, false)
 	s.Name = options.ContainerName
 	s.Hostname = options.ContainerName
 
-	if s.Labels == nil {
-		s.Labels = map[string]string{}
+	s.Labels = containerLabels(options)
+
+	// containers of a pod use network and ports of the pod
+	if options.Pod != "" {
+		s.Pod = options.Pod
+		s.Hostname = ""
+	} else {
+		s.Networks = podmanNetworks(options)
+		s.PortMappings = podmanPortMappings(options)
 	}
-	s.Labels["app.name"] = options.ApplicationName
-	s.Labels["app.container"] = options.ContainerName
-	s.Labels["app.subdomain"] = options.SubDomain
 
-	if options.NetworkName != "" {
-		if s.Networks == nil {
-			s.Networks = map[string]nettypes.PerNetworkOptions{}
+	for _, mount := range options.MountDirs {
+		if err = mount.validate(); err != nil {
+			return err
+		}
+
+		switch mount.kind() {
+		case MountBind:
+			s.Mounts = append(s.Mounts, spec.Mount{
+				Source:      mount.Source,
+				Destination: mount.Destination,
+				Type:        "bind",
+				// "rbind" preserves sub-mount propagation
+				Options: slices.Concat(
+					[]string{"rbind"}, mount.options(), mount.ownershipOptions(),
+				),
+			})
+		case MountVolume:
+			s.Volumes = append(s.Volumes, &specgen.NamedVolume{
+				Name:    mount.Source,
+				Dest:    mount.Destination,
+				Options: append(mount.options(), mount.ownershipOptions()...),
+			})
+		case MountTmpfs:
+			s.Mounts = append(s.Mounts, spec.Mount{
+				Source:      "tmpfs",
+				Destination: mount.Destination,
+				Type:        "tmpfs",
+				Options:     mount.options(),
+			})
 		}
-		s.Networks[options.NetworkName] = nettypes.PerNetworkOptions{}
 	}
 
-	for hostPort, containerPort := range options.BindPorts {
-		s.PortMappings = append(s.PortMappings, nettypes.PortMapping{
-			HostPort:      uint16(hostPort),
-			ContainerPort: uint16(containerPort),
-			Protocol:      "tcp",
-		})
+	if err = options.Egress.validate(); err != nil {
+		return err
+	}
+	if err = options.Identity.validate(); err != nil {
+		return err
+	}
+	s.User = options.Identity.userSpec()
+	s.Groups = options.Identity.GroupAdd
+	// containers of a pod share the user namespace of the pod
+	if options.Pod == "" {
+		s.UserNS, s.IDMappings, err = podmanUserNS(options.Identity)
+		if err != nil {
+			return err
+		}
 	}
 
-	// Mounts: host path -> container path as bind mounts
-	for hostDir, containerDir := range options.MountDirs {
-		s.Mounts = append(s.Mounts, spec.Mount{
-			Source:      hostDir,
-			Destination: containerDir,
-			Type:        "bind",
-			// "rbind" preserves sub-mount propagation
-			// "rw" for read-write / Use "ro" for read-only
-			Options: []string{"rbind", "ro"},
-		})
+	s.ResourceLimits, err = options.Resources.linuxResources()
+	if err != nil {
+		return err
+	}
+
+	health, err := options.healthConfig()
+	if err != nil {
+		return err
+	}
+	if health != nil {
+		s.HealthConfig = &manifest.Schema2HealthConfig{
+			Test:        health.Test,
+			Interval:    health.Interval,
+			Timeout:     health.Timeout,
+			StartPeriod: health.StartPeriod,
+			Retries:     health.Retries,
+		}
 	}
 
-	s.RestartPolicy = "always"
+	if err = podmanLifecycle(s, options.Lifecycle); err != nil {
+		return err
+	}
 
-	// s.Env = map[string]string{
-	//     "APP_NAME":  applicationName,
-	//     "SUBDOMAIN": subdomain,
-	// }
+	s.Env = options.Environment()
 
-	containerExists, err := containers.Exists(conn, options.ContainerName, nil)
+	containerExists, err := containers.Exists(ctx, options.ContainerName, nil)
 	if err != nil {
 		slog.Debug("Failed to check if container already exists", "error", err)
 		return err
@@ -258,7 +606,11 @@ func (conn PodManContext) CreateContainer(options Config) (err error) {
 		return nil
 	}
 
-	ctr, err := containers.CreateWithSpec(conn, s, nil)
+	if err = conn.ensureVolumes(ctx, options); err != nil {
+		return err
+	}
+
+	ctr, err := containers.CreateWithSpec(ctx, s, nil)
 	if err != nil {
 		return fmt.Errorf("create container %q failed: %w", options.ContainerName, err)
 	}
@@ -266,40 +618,420 @@ func (conn PodManContext) CreateContainer(options Config) (err error) {
 	return nil
 }
 
-func (conn PodManContext) RemoveContainer(containerName string, force bool) (
+// Creates named volumes of the container that do not exist yet, labelled as
+// owned by the application
+func (conn PodManContext) ensureVolumes(
+	ctx context.Context,
+	options Config,
+) (err error) {
+	for _, mount := range options.MountDirs {
+		if mount.kind() != MountVolume {
+			continue
+		}
+
+		exists, err := volumes.Exists(ctx, mount.Source, nil)
+		if err != nil {
+			return err
+		}
+		if exists {
+			continue
+		}
+
+		_, err = volumes.Create(ctx, entities.VolumeCreateOptions{
+			Name:  mount.Source,
+			Label: ownerLabels(options),
+		}, nil)
+		if err != nil {
+			return fmt.Errorf("create volume %q failed: %w", mount.Source, err)
+		}
+		slog.Debug("Created volume", "name", mount.Source)
+	}
+	return nil
+}
+
+func podmanLifecycle(s *specgen.SpecGenerator, lifecycle Lifecycle) error {
+	if err := lifecycle.validate(); err != nil {
+		return err
+	}
+
+	s.RestartPolicy = lifecycle.restartPolicy()
+	if lifecycle.MaxRetries > 0 {
+		retries := lifecycle.MaxRetries
+		s.RestartRetries = &retries
+	}
+
+	stopSignal, _ := lifecycle.stopSignal()
+	if stopSignal != 0 {
+		s.StopSignal = &stopSignal
+	}
+	stopSeconds, _ := lifecycle.stopSeconds()
+	if stopSeconds > 0 {
+		s.StopTimeout = &stopSeconds
+	}
+	return nil
+}
+
+// User namespace of the identity and id mappings of its private namespace.
+// Runtime default when neither is set
+func podmanUserNS(identity Identity) (
+	userNS specgen.Namespace,
+	idMappings *storagetypes.IDMappingOptions,
 	err error,
 ) {
+	if identity.hasIDMaps() {
+		idMappings = &storagetypes.IDMappingOptions{
+			UIDMap: podmanIDMaps(identity.UIDMap),
+			GIDMap: podmanIDMaps(identity.gidMap()),
+		}
+		return specgen.Namespace{NSMode: specgen.Private}, idMappings, nil
+	}
+	if identity.UserNS == "" {
+		return userNS, nil, nil
+	}
+
+	userNS, err = specgen.ParseUserNamespace(identity.UserNS)
+	if err != nil {
+		return userNS, nil, fmt.Errorf("user namespace %q: %w", identity.UserNS, err)
+	}
+	return userNS, nil, nil
+}
+
+func podmanIDMaps(idMaps []IDMap) (mappings []idtools.IDMap) {
+	for _, idMap := range idMaps {
+		mappings = append(mappings, idtools.IDMap{
+			ContainerID: idMap.ContainerID,
+			HostID:      idMap.HostID,
+			Size:        idMap.Size,
+		})
+	}
+	return mappings
+}
+
+func podmanNetworks(options Config) map[string]nettypes.PerNetworkOptions {
+	networks := options.Networks()
+	if len(networks) == 0 {
+		return nil
+	}
+
+	perNetwork := map[string]nettypes.PerNetworkOptions{}
+	for _, network := range networks {
+		// router and other apps reach the app by its container name
+		perNetwork[network] = nettypes.PerNetworkOptions{
+			Aliases: []string{options.ContainerName},
+		}
+	}
+	return perNetwork
+}
+
+func podmanPortMappings(options Config) (mappings []nettypes.PortMapping) {
+	for hostPort, containerPort := range options.BindPorts {
+		mappings = append(mappings, nettypes.PortMapping{
+			HostPort:      uint16(hostPort),
+			ContainerPort: uint16(containerPort),
+			Protocol:      "tcp",
+		})
+	}
+	return mappings
+}
+
+// Creates pod for the app. The pod owns network and published ports of the
+// app, containers of the stack join it
+func (conn PodManContext) CreatePod(
+	ctx context.Context,
+	options Config,
+) (err error) {
+	ctx, cancel := withTimeout(ctx, conn.timeouts.Create)
+	defer cancel()
+	ctx, release := conn.bind(ctx)
+	defer release()
+
+	podSpec := specgen.NewPodSpecGenerator()
+	podSpec.Name = options.PodName()
+	podSpec.Hostname = options.ContainerName
+	podSpec.Labels = podLabels(options)
+	podSpec.Networks = podmanNetworks(options)
+	podSpec.PortMappings = podmanPortMappings(options)
+
+	if err = options.Egress.validate(); err != nil {
+		return err
+	}
+	if err = options.Identity.validate(); err != nil {
+		return err
+	}
+	podSpec.Userns, podSpec.IDMappings, err = podmanUserNS(options.Identity)
+	if err != nil {
+		return err
+	}
+
+	report, err := pods.CreatePodFromSpec(
+		ctx, &entities.PodSpec{PodSpecGen: *podSpec},
+	)
+	if err != nil {
+		return err
+	}
+	slog.Debug("Pod created", "response", report)
+	return nil
+}
+
+func (conn PodManContext) StartPod(
+	ctx context.Context,
+	podName string,
+) (err error) {
+	ctx, release := conn.bind(ctx)
+	defer release()
+
+	_, err = pods.Start(ctx, podName, nil)
+	return
+}
+
+func (conn PodManContext) StopPod(ctx context.Context, podName string) (err error) {
+	ctx, release := conn.bind(ctx)
+	defer release()
+
+	stopOptions := new(pods.StopOptions)
+	if seconds, ok := conn.timeouts.stopSeconds(); ok {
+		stopOptions = stopOptions.WithTimeout(int(seconds))
+	}
+	_, err = pods.Stop(ctx, podName, stopOptions)
+	return
+}
+
+func (conn PodManContext) RemovePod(
+	ctx context.Context,
+	podName string,
+	force bool,
+) (err error) {
+	ctx, release := conn.bind(ctx)
+	defer release()
+
+	options := new(pods.RemoveOptions).WithForce(force)
+	_, err = pods.Remove(ctx, podName, options)
+	return
+}
+
+func (conn PodManContext) PodExists(ctx context.Context, podName string) (
+	exists bool, err error,
+) {
+	ctx, release := conn.bind(ctx)
+	defer release()
+
+	return pods.Exists(ctx, podName, nil)
+}
+
+// Lists pods created by rocket
+func (conn PodManContext) ListManagedPods(ctx context.Context) (
+	managed []ManagedPod, err error,
+) {
+	ctx, release := conn.bind(ctx)
+	defer release()
+
+	options := new(pods.ListOptions).
+		WithFilters(map[string][]string{"label": {managedFilter}})
+
+	podList, err := pods.List(ctx, options)
+	if err != nil {
+		return
+	}
+
+	for _, pod := range podList {
+		managed = append(managed, ManagedPod{
+			Name:   pod.Name,
+			Labels: pod.Labels,
+		})
+	}
+
+	return
+}
+
+func (conn PodManContext) RemoveContainer(
+	ctx context.Context,
+	containerName string,
+	force bool,
+) (err error) {
+	ctx, release := conn.bind(ctx)
+	defer release()
+
 	options := containers.RemoveOptions{
 		Force: &force,
 	}
-	_, err = containers.Remove(conn, containerName, &options)
+	_, err = containers.Remove(ctx, containerName, &options)
 	return
 }
 
-func (conn PodManContext) StartService(containerName string) (err error) {
-	if err := containers.Start(conn, containerName, nil); err != nil {
+func (conn PodManContext) StartService(ctx context.Context, containerName string) (
+	err error,
+) {
+	ctx, release := conn.bind(ctx)
+	defer release()
+
+	if err := containers.Start(ctx, containerName, nil); err != nil {
 		return err
 	}
 	return nil
 }
 
-func (conn PodManContext) StopService(containerName string) (err error) {
-	if err := containers.Stop(conn, containerName, nil); err != nil {
+func (conn PodManContext) StopService(ctx context.Context, containerName string) (
+	err error,
+) {
+	ctx, release := conn.bind(ctx)
+	defer release()
+
+	stopOptions := new(containers.StopOptions)
+	if seconds, ok := conn.timeouts.stopSeconds(); ok {
+		stopOptions = stopOptions.WithTimeout(seconds)
+	}
+	if err := containers.Stop(ctx, containerName, stopOptions); err != nil {
 		return err
 	}
 	return nil
 }
 
-func (conn PodManContext) PauseService(containerName string) (err error) {
-	return nil
+func (conn PodManContext) PauseService(ctx context.Context, containerName string) (
+	err error,
+) {
+	ctx, release := conn.bind(ctx)
+	defer release()
+
+	return containers.Pause(ctx, containerName, nil)
 }
 
-func (conn PodManContext) UpdateService(containerName string) (err error) {
-	return nil
+func (conn PodManContext) UnpauseService(ctx context.Context, containerName string) (
+	err error,
+) {
+	ctx, release := conn.bind(ctx)
+	defer release()
+
+	return containers.Unpause(ctx, containerName, nil)
 }
 
-func (conn PodManContext) ListNetworks() (networks []string, err error) {
-	networkList, err := network.List(conn, nil)
+// Pulls newest image for the container and recreates the container when the
+// pulled image differs from the one the container runs
+func (conn PodManContext) UpdateService(ctx context.Context, options Config) (
+	updated bool, err error,
+) {
+	ctx, release := conn.bind(ctx)
+	defer release()
+
+	exists, err := containers.Exists(ctx, options.ContainerName, nil)
+	if err != nil {
+		return false, err
+	}
+	if !exists {
+		return false, ContainerDoesntExistErr
+	}
+
+	ctrData, err := containers.Inspect(ctx, options.ContainerName, nil)
+	if err != nil {
+		return false, err
+	}
+
+	image := options.Image()
+	pullCtx, cancel := withTimeout(ctx, conn.timeouts.Pull)
+	pulledID, err := conn.pull(pullCtx, image, PullOptions{})
+	cancel()
+	if err != nil {
+		return false, err
+	}
+
+	slog.Debug(
+		"Compared container image",
+		"container", options.ContainerName,
+		"current", ctrData.Image,
+		"pulled", pulledID,
+	)
+	if pulledID == ctrData.Image {
+		return false, nil
+	}
+
+	wasRunning := ctrData.State != nil && ctrData.State.Running
+	if err = recreateService(ctx, conn, options, wasRunning); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Lists volumes created by rocket
+func (conn PodManContext) ListManagedVolumes(ctx context.Context) (
+	managed []ManagedVolume, err error,
+) {
+	ctx, release := conn.bind(ctx)
+	defer release()
+
+	options := new(volumes.ListOptions).
+		WithFilters(map[string][]string{"label": {managedFilter}})
+
+	volumeList, err := volumes.List(ctx, options)
+	if err != nil {
+		return
+	}
+
+	for _, volume := range volumeList {
+		managed = append(managed, ManagedVolume{
+			Name:   volume.Name,
+			Labels: volume.Labels,
+		})
+	}
+
+	return
+}
+
+func (conn PodManContext) RemoveVolume(
+	ctx context.Context,
+	volumeName string,
+	force bool,
+) (err error) {
+	ctx, release := conn.bind(ctx)
+	defer release()
+
+	options := new(volumes.RemoveOptions).WithForce(force)
+	return volumes.Remove(ctx, volumeName, options)
+}
+
+// Writes the contents of the volume as a tar archive
+func (conn PodManContext) ExportVolume(
+	ctx context.Context,
+	volumeName string,
+	contents io.Writer,
+) (err error) {
+	ctx, release := conn.bind(ctx)
+	defer release()
+
+	exists, err := volumes.Exists(ctx, volumeName, nil)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return VolumeDoesntExistErr
+	}
+	return volumes.Export(ctx, volumeName, contents)
+}
+
+// Extracts the tar archive into the volume, keeping files it does not contain
+func (conn PodManContext) ImportVolume(
+	ctx context.Context,
+	volumeName string,
+	contents io.Reader,
+) (err error) {
+	ctx, release := conn.bind(ctx)
+	defer release()
+
+	exists, err := volumes.Exists(ctx, volumeName, nil)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return VolumeDoesntExistErr
+	}
+	return volumes.Import(ctx, volumeName, contents)
+}
+
+func (conn PodManContext) ListNetworks(ctx context.Context) (
+	networks []string, err error,
+) {
+	ctx, release := conn.bind(ctx)
+	defer release()
+
+	networkList, err := network.List(ctx, nil)
 
 	for _, network := range networkList {
 		networks = append(networks, network.Name)
@@ -308,19 +1040,48 @@ func (conn PodManContext) ListNetworks() (networks []string, err error) {
 	return
 }
 
-func (conn PodManContext) CreateNetwork(networkName string) (err error) {
+func (conn PodManContext) CreateNetwork(
+	ctx context.Context,
+	networkName string,
+	internal bool,
+) (err error) {
+	ctx, release := conn.bind(ctx)
+	defer release()
+
 	_, err = network.Create(
-		conn, &nettypes.Network{
+		ctx, &nettypes.Network{
 			Name:       networkName,
-			Internal:   true,
+			Internal:   internal,
 			DNSEnabled: true,
 		},
 	)
 	return err
 }
 
-func (conn PodManContext) NetworkExists(networkName string) (
-	exists bool, err error,
-) {
-	return network.Exists(conn, networkName, nil)
+func (conn PodManContext) NetworkExists(
+	ctx context.Context,
+	networkName string,
+) (exists bool, err error) {
+	ctx, release := conn.bind(ctx)
+	defer release()
+
+	return network.Exists(ctx, networkName, nil)
+}
+
+// Subnets of the network in CIDR notation e.g. "10.89.1.0/24"
+func (conn PodManContext) NetworkSubnets(
+	ctx context.Context,
+	networkName string,
+) (subnets []string, err error) {
+	ctx, release := conn.bind(ctx)
+	defer release()
+
+	report, err := network.Inspect(ctx, networkName, nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, subnet := range report.Subnets {
+		subnets = append(subnets, subnet.Subnet.String())
+	}
+	return subnets, nil
 }
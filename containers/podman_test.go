@@ -0,0 +1,30 @@
+package containers_test
+
+import (
+	"testing"
+
+	"ayayushsharma/rocket/containers"
+	"ayayushsharma/rocket/containers/containertest"
+)
+
+func TestPodman(t *testing.T) {
+	containertest.Run(t, func(t *testing.T) containers.ContainerManager {
+		return connectOrSkip(t, containers.RuntimePodman)
+	})
+}
+
+// Manager of the runtime, skipping the test when its socket can not be
+// reached
+func connectOrSkip(t *testing.T, runtime string) containers.ContainerManager {
+	t.Helper()
+
+	conn, err := containers.Manager(containers.ConnectOptions{Runtime: runtime})
+	if err != nil {
+		t.Skipf("%s not reachable: %v", runtime, err)
+	}
+	// connecting alone does not reach every runtime
+	if _, err = conn.ListNetworks(t.Context()); err != nil {
+		t.Skipf("%s not reachable: %v", runtime, err)
+	}
+	return conn
+}
@@ -0,0 +1,81 @@
+package containers
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// Options for pulling an image
+type PullOptions struct {
+	// called with every progress update of the pull. Progress is not
+	// collected when nil
+	Progress func(PullProgress)
+}
+
+// Progress update of a pull
+type PullProgress struct {
+	// layer the update is about. Empty for updates about the whole image
+	Layer string
+
+	// status reported by the runtime e.g. "Downloading", "Copying blob"
+	Status string
+
+	// bytes of the layer transferred so far and size of the layer. Zero when
+	// the runtime does not report byte counts
+	Current int64
+	Total   int64
+
+	// set on the final update, Total then is the size of the pulled image
+	Done bool
+}
+
+func (o PullOptions) report(progress PullProgress) {
+	if o.Progress != nil {
+		o.Progress(progress)
+	}
+}
+
+// Writer turning text progress of podman into progress updates. Podman only
+// reports when copying of a blob starts and finishes, without byte counts.
+// Caller closes the writer once the pull returns and waits on done
+func (o PullOptions) podmanProgressWriter() (
+	writer *io.PipeWriter, done chan struct{},
+) {
+	reader, writer := io.Pipe()
+	done = make(chan struct{})
+
+	go func() {
+		defer close(done)
+		scanner := bufio.NewScanner(reader)
+		for scanner.Scan() {
+			o.report(parsePodmanProgress(scanner.Text()))
+		}
+		// keep draining so that the pull never blocks on the pipe
+		_, _ = io.Copy(io.Discard, reader)
+	}()
+
+	return writer, done
+}
+
+// Parses lines like "Copying blob 9ad63333ebc9 done"
+func parsePodmanProgress(line string) PullProgress {
+	line = strings.TrimSpace(line)
+	fields := strings.Fields(line)
+	if len(fields) < 3 || fields[0] != "Copying" || fields[1] != "blob" {
+		return PullProgress{Status: line}
+	}
+
+	progress := PullProgress{
+		Layer:  strings.TrimPrefix(fields[2], "sha256:"),
+		Status: "Copying blob",
+	}
+	rest := strings.Join(fields[3:], " ")
+	switch {
+	case strings.Contains(rest, "skipped"):
+		progress.Status = "Already exists"
+	case strings.Contains(rest, "done"):
+		progress.Status = "Pull complete"
+	}
+	return progress
+}
@@ -0,0 +1,116 @@
+package containers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	spec "github.com/opencontainers/runtime-spec/specs-go"
+)
+
+// Period over which CPU quota of a container is enforced, in microseconds
+const cpuPeriod = 100000
+
+// Resource limits of a container. Zero values leave the limit unset
+type Resources struct {
+	// memory limit e.g. "512m", "2g"
+	Memory string `json:",omitempty"`
+
+	// memory plus swap limit e.g. "1g". "-1" allows unlimited swap
+	MemorySwap string `json:",omitempty"`
+
+	// number of CPUs the container may use e.g. 1.5
+	CPUs float64 `json:",omitempty"`
+
+	// relative CPU weight against other containers
+	CPUShares uint64 `json:",omitempty"`
+
+	// maximum number of processes in the container
+	PidsLimit int64 `json:",omitempty"`
+}
+
+func (r Resources) IsZero() bool {
+	return r == Resources{}
+}
+
+// Multipliers of the size suffixes, case-insensitive
+var sizeSuffixes = map[string]int64{
+	"":   1,
+	"b":  1,
+	"k":  1 << 10,
+	"kb": 1 << 10,
+	"m":  1 << 20,
+	"mb": 1 << 20,
+	"g":  1 << 30,
+	"gb": 1 << 30,
+}
+
+// Parses sizes with b, k, m or g suffix into bytes, kb, mb and gb being the
+// same as k, m and g. "-1" means unlimited
+func parseSize(size string) (bytes int64, err error) {
+	size = strings.ToLower(strings.TrimSpace(size))
+	if size == "-1" {
+		return -1, nil
+	}
+
+	number := strings.TrimRightFunc(size, unicode.IsLetter)
+	multiplier, ok := sizeSuffixes[size[len(number):]]
+	if !ok {
+		return 0, fmt.Errorf("invalid size %q, unknown suffix %q", size, size[len(number):])
+	}
+
+	value, err := strconv.ParseFloat(number, 64)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("invalid size %q", size)
+	}
+	return int64(value * float64(multiplier)), nil
+}
+
+// Converts resources to limits understood by OCI runtimes
+func (r Resources) linuxResources() (limits *spec.LinuxResources, err error) {
+	if r.IsZero() {
+		return nil, nil
+	}
+
+	limits = &spec.LinuxResources{}
+
+	if r.Memory != "" || r.MemorySwap != "" {
+		limits.Memory = &spec.LinuxMemory{}
+	}
+	if r.Memory != "" {
+		memory, err := parseSize(r.Memory)
+		if err != nil {
+			return nil, fmt.Errorf("memory limit: %w", err)
+		}
+		limits.Memory.Limit = &memory
+	}
+	if r.MemorySwap != "" {
+		swap, err := parseSize(r.MemorySwap)
+		if err != nil {
+			return nil, fmt.Errorf("memory swap limit: %w", err)
+		}
+		limits.Memory.Swap = &swap
+	}
+
+	if r.CPUs > 0 || r.CPUShares > 0 {
+		limits.CPU = &spec.LinuxCPU{}
+	}
+	if r.CPUs > 0 {
+		quota := int64(r.CPUs * cpuPeriod)
+		period := uint64(cpuPeriod)
+		limits.CPU.Quota = &quota
+		limits.CPU.Period = &period
+	}
+	if r.CPUShares > 0 {
+		shares := r.CPUShares
+		limits.CPU.Shares = &shares
+	}
+
+	if r.PidsLimit != 0 {
+		pids := r.PidsLimit
+		limits.Pids = &spec.LinuxPids{Limit: &pids}
+	}
+
+	return limits, nil
+}
@@ -0,0 +1,37 @@
+package containers
+
+import "testing"
+
+func TestParseSize(t *testing.T) {
+	valid := map[string]int64{
+		"-1":     -1,
+		"512":    512,
+		"100b":   100,
+		"64k":    64 << 10,
+		"64KB":   64 << 10,
+		"512m":   512 << 20,
+		"512mb":  512 << 20,
+		"1.5g":   3 << 29,
+		" 2GB ":  2 << 30,
+		"2Gb":    2 << 30,
+		"0":      0,
+		"0.5kb":  512,
+		"1024Kb": 1 << 20,
+	}
+	for size, want := range valid {
+		got, err := parseSize(size)
+		if err != nil {
+			t.Errorf("parseSize(%q): %v", size, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("parseSize(%q) = %d, want %d", size, got, want)
+		}
+	}
+
+	for _, size := range []string{"", "g", "512x", "512mbb", "1tb", "2gib", "-2m", "m512", "inf"} {
+		if got, err := parseSize(size); err == nil {
+			t.Errorf("parseSize(%q) = %d, want error", size, got)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package containers
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+)
+
+// Apps with a Stack run as a pod. Web container of the app and its stack
+// containers share the network namespace of the pod, so stack containers are
+// reachable on localhost from the app. Only the web container is routed.
+
+const podSuffix = "-pod"
+
+// Name of the pod an app with a stack runs in
+func (c Config) PodName() string {
+	return c.ContainerName + podSuffix
+}
+
+func (c Config) IsStack() bool {
+	return len(c.Stack) > 0
+}
+
+// Configs of every container of the stack, web container first. Stack
+// container names are prefixed with the app's container name
+func (c Config) StackContainers() (stack []Config) {
+	web := c
+	web.Stack = nil
+	web.Pod = c.PodName()
+	stack = append(stack, web)
+
+	for _, member := range c.Stack {
+		name := member.ContainerName
+		if name == "" {
+			name = member.ApplicationName
+		}
+		member.ContainerName = c.ContainerName + "-" + name
+		member.Pod = c.PodName()
+		member.Stack = nil
+		// network, egress and ports belong to the pod
+		member.NetworkName = ""
+		member.Egress = Egress{}
+		member.EgressProxy = c.EgressProxy
+		member.BindPorts = nil
+		stack = append(stack, member)
+	}
+
+	return stack
+}
+
+// Images that need to be present to create the app
+func (c Config) Images() (images []string) {
+	images = append(images, c.Image())
+	for _, member := range c.Stack {
+		images = append(images, member.Image())
+	}
+	return images
+}
+
+// Image references with versions of the app and its stack, as written in
+// the workspace
+func (c Config) ImageRefs() (refs []string) {
+	refs = append(refs, c.ImageRef())
+	for _, member := range c.Stack {
+		refs = append(refs, member.ImageRef())
+	}
+	return refs
+}
+
+// Copy of the config with images of the app and its stack pinned to the
+// digests of their references. Images without a pin are left as they are
+func (c Config) Pin(pins map[string]string) Config {
+	c.ImageDigest = pins[c.ImageRef()]
+
+	stack := make([]Config, 0, len(c.Stack))
+	for _, member := range c.Stack {
+		member.ImageDigest = pins[member.ImageRef()]
+		stack = append(stack, member)
+	}
+	if len(stack) > 0 {
+		c.Stack = stack
+	}
+
+	return c
+}
+
+// Labels of pods created for the app
+func podLabels(options Config) map[string]string {
+	labels := ownerLabels(options)
+	labels[LabelSubDomain] = options.SubDomain
+	return labels
+}
+
+// Creates pod of the app followed by every container of the stack.
+// Already existing pod and containers are left as they are
+func CreateStack(
+	ctx context.Context,
+	conn ContainerManager,
+	options Config,
+) (err error) {
+	podName := options.PodName()
+
+	exists, err := conn.PodExists(ctx, podName)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		if err = conn.CreatePod(ctx, options); err != nil {
+			return fmt.Errorf("create pod %q failed: %w", podName, err)
+		}
+		slog.Debug("Created pod", "name", podName)
+	}
+
+	for _, member := range options.StackContainers() {
+		if err = conn.CreateContainer(ctx, member); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func StartStack(ctx context.Context, conn ContainerManager, options Config) error {
+	return conn.StartPod(ctx, options.PodName())
+}
+
+func StopStack(ctx context.Context, conn ContainerManager, options Config) error {
+	return conn.StopPod(ctx, options.PodName())
+}
+
+// Recreates containers of the stack whose image changed, each in the pod of
+// the app. Fails with ContainerDoesntExistErr when the pod was not created
+func UpdateStack(
+	ctx context.Context,
+	conn ContainerManager,
+	options Config,
+) (updated bool, err error) {
+	exists, err := conn.PodExists(ctx, options.PodName())
+	if err != nil {
+		return false, err
+	}
+	if !exists {
+		return false, ContainerDoesntExistErr
+	}
+
+	for _, member := range options.StackContainers() {
+		memberUpdated, err := conn.UpdateService(ctx, member)
+		if err != nil {
+			return updated, fmt.Errorf("update %q: %w", member.ContainerName, err)
+		}
+		updated = updated || memberUpdated
+	}
+	return updated, nil
+}
+
+// Removes pod of the app along with all of its containers
+func RemoveStack(
+	ctx context.Context,
+	conn ContainerManager,
+	options Config,
+	force bool,
+) error {
+	return conn.RemovePod(ctx, options.PodName(), force)
+}
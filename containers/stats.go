@@ -0,0 +1,45 @@
+package containers
+
+import "time"
+
+// Resource usage of a running container at one point in time. Counters are
+// totals since the container started
+type ContainerStats struct {
+	Name string `json:"name"`
+
+	// CPU usage in percent of one core, as computed by the runtime
+	CPUPercent float64 `json:"cpuPercent"`
+
+	// CPU time used by the container
+	CPUTime time.Duration `json:"cpuTimeNs"`
+
+	// memory in use and the limit it may grow to, in bytes
+	MemoryUsage uint64 `json:"memoryUsage"`
+	MemoryLimit uint64 `json:"memoryLimit"`
+
+	// bytes received and sent over the network
+	NetInput  uint64 `json:"netInput"`
+	NetOutput uint64 `json:"netOutput"`
+
+	// bytes read from and written to block devices
+	BlockInput  uint64 `json:"blockInput"`
+	BlockOutput uint64 `json:"blockOutput"`
+
+	PIDs uint64 `json:"pids"`
+
+	// time the sample was taken
+	Read time.Time `json:"read"`
+}
+
+// CPU usage between an earlier sample of the same container and this one,
+// in percent of one core. Runtimes computing usage since start report stale
+// numbers for long running containers, deltas of two samples do not
+func (s ContainerStats) CPUPercentSince(previous ContainerStats) (
+	percent float64, ok bool,
+) {
+	elapsed := s.Read.Sub(previous.Read)
+	if elapsed <= 0 || s.CPUTime < previous.CPUTime {
+		return 0, false
+	}
+	return float64(s.CPUTime-previous.CPUTime) / float64(elapsed) * 100, true
+}
@@ -0,0 +1,57 @@
+package containers
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// States reported by runtimes for containers that ran
+const (
+	StateRunning = "running"
+	StateExited  = "exited"
+)
+
+// Live state of a container as reported by the container runtime
+type ContainerStatus struct {
+	Name string
+
+	// state reported by the runtime e.g. "running", "exited", "created"
+	State string
+
+	// exit code of the last run. Only meaningful when container has exited
+	ExitCode int
+
+	StartedAt time.Time
+
+	// number of times runtime restarted the container due to restart policy
+	RestartCount int
+
+	// health reported by the healthcheck. Empty when there is no healthcheck
+	Health string
+
+	// digest or ID of the image container is running
+	ImageDigest string
+
+	// published ports of the container
+	// ports["HOST_PORT"] = "CONTAINER_PORT"
+	Ports map[int]int
+
+	// networks the container joined, sorted
+	Networks []string
+}
+
+func (s ContainerStatus) Running() bool {
+	return s.State == StateRunning
+}
+
+// Running containers are healthy unless their healthcheck says otherwise
+func (s ContainerStatus) Healthy() bool {
+	return s.Running() && s.Health != HealthUnhealthy
+}
+
+// Parses "80/tcp" style port keys used by runtimes into port numbers
+func parsePortKey(key string) (port int, err error) {
+	portNumber, _, _ := strings.Cut(key, "/")
+	return strconv.Atoi(portNumber)
+}
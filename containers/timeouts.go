@@ -0,0 +1,39 @@
+package containers
+
+import (
+	"context"
+	"time"
+)
+
+// Limits on slow container runtime operations. Zero values leave the
+// operation bound only by the context passed by the caller
+type Timeouts struct {
+	// pulling an image
+	Pull time.Duration
+
+	// creating a container or pod along with its volumes
+	Create time.Duration
+
+	// time a container gets to exit after the stop signal before it is
+	// killed, overriding the stop timeout of the container. Stop timeout of
+	// the container applies if zero
+	StopGrace time.Duration
+}
+
+// Derives context bounded by timeout, ctx is returned as is for zero timeout
+func withTimeout(ctx context.Context, timeout time.Duration) (
+	context.Context, context.CancelFunc,
+) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// Stop grace period in whole seconds as the runtimes expect it
+func (t Timeouts) stopSeconds() (seconds uint, ok bool) {
+	if t.StopGrace <= 0 {
+		return 0, false
+	}
+	return uint(t.StopGrace.Round(time.Second) / time.Second), true
+}
@@ -0,0 +1,160 @@
+// Config of the proxy filtering outbound traffic of apps with an allowlist.
+//
+// The proxy runs squid on the egress network and on the proxy network of
+// every allowlisted app, which only the app and the proxy join. Every
+// allowlisted app gets a port of its own, so the port a request arrives on
+// tells which allowlist applies, and requests only pass when they come from
+// the subnet of the proxy network of that app. Ports are kept across syncs,
+// apps keep their proxy address as long as they stay allowlisted.
+
+package egress
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"ayayushsharma/rocket/constants"
+	"ayayushsharma/rocket/containers"
+)
+
+// Port of the proxy not assigned to any app, it denies everything
+const defaultPort = 3128
+
+// Path of the config inside the proxy container
+const ProxyConfPath = "/etc/squid/squid.conf"
+
+// Assigns proxy ports to apps with an allowlist and writes the proxy config.
+// subnets are the subnets of the proxy network of every allowlisted app.
+// Returns the port of every allowlisted app and whether the config changed
+func Sync(apps map[string]containers.Config, subnets map[string][]string) (
+	ports map[string]int, changed bool, err error,
+) {
+	ports, err = assignPorts(apps)
+	if err != nil {
+		return nil, false, err
+	}
+
+	conf := render(apps, ports, subnets)
+	current, err := os.ReadFile(constants.EgressProxyConf)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, false, err
+	}
+	if bytes.Equal(current, conf) {
+		return ports, false, nil
+	}
+
+	if err = os.MkdirAll(filepath.Dir(constants.EgressProxyConf), 0755); err != nil {
+		return nil, false, err
+	}
+	if err = os.WriteFile(constants.EgressProxyConf, conf, 0644); err != nil {
+		return nil, false, err
+	}
+	slog.Debug("Wrote egress proxy config", "apps", len(ports))
+	return ports, true, nil
+}
+
+// URL apps reach their port of the proxy on
+func ProxyURL(port int) string {
+	return fmt.Sprintf("http://%s:%d", constants.EgressProxyContainer, port)
+}
+
+// Keeps ports of apps still allowlisted and gives new ones the lowest free
+// port. Assignments are saved for the next sync
+func assignPorts(apps map[string]containers.Config) (
+	ports map[string]int, err error,
+) {
+	saved := map[string]int{}
+	data, err := os.ReadFile(constants.EgressPortsJson)
+	if err == nil {
+		err = json.Unmarshal(data, &saved)
+	}
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	ports = map[string]int{}
+	used := map[int]bool{defaultPort: true}
+	for appName, port := range saved {
+		if apps[appName].Egress.Mode() == containers.EgressAllowlist {
+			ports[appName] = port
+			used[port] = true
+		}
+	}
+
+	for _, appName := range slices.Sorted(maps.Keys(apps)) {
+		if apps[appName].Egress.Mode() != containers.EgressAllowlist {
+			continue
+		}
+		if _, ok := ports[appName]; ok {
+			continue
+		}
+		port := defaultPort + 1
+		for used[port] {
+			port++
+		}
+		ports[appName] = port
+		used[port] = true
+	}
+
+	if maps.Equal(ports, saved) {
+		return ports, nil
+	}
+
+	data, err = json.MarshalIndent(ports, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err = os.MkdirAll(filepath.Dir(constants.EgressPortsJson), 0755); err != nil {
+		return nil, err
+	}
+	return ports, os.WriteFile(constants.EgressPortsJson, data, 0644)
+}
+
+// Apps without a known subnet get their port but nothing is allowed on it
+func render(
+	apps map[string]containers.Config,
+	ports map[string]int,
+	subnets map[string][]string,
+) []byte {
+	var b bytes.Buffer
+	fmt.Fprintln(&b, "# Generated by rocket, changes are overwritten")
+	fmt.Fprintf(&b, "http_port %d name=default\n", defaultPort)
+	fmt.Fprintln(&b, "acl CONNECT method CONNECT")
+	fmt.Fprintln(&b, "acl SSL_ports port 443")
+	fmt.Fprintln(&b, "http_access deny CONNECT !SSL_ports")
+
+	for _, appName := range slices.Sorted(maps.Keys(ports)) {
+		hosts := []string{}
+		for _, host := range apps[appName].Egress.Allow {
+			// leading dot matches the domain and all of its subdomains
+			hosts = append(hosts, strings.Replace(host, "*.", ".", 1))
+		}
+
+		fmt.Fprintln(&b)
+		fmt.Fprintf(&b, "http_port %d name=%s\n", ports[appName], appName)
+		fmt.Fprintf(&b, "acl %s_port myportname %s\n", appName, appName)
+		fmt.Fprintf(&b, "acl %s_hosts dstdomain %s\n", appName, strings.Join(hosts, " "))
+		if len(subnets[appName]) == 0 {
+			continue
+		}
+		// any container can connect to the port, only the app is on the subnet
+		fmt.Fprintf(&b, "acl %s_src src %s\n", appName, strings.Join(subnets[appName], " "))
+		fmt.Fprintf(
+			&b, "http_access allow %s_port %s_src %s_hosts\n", appName, appName, appName,
+		)
+	}
+
+	fmt.Fprintln(&b)
+	fmt.Fprintln(&b, "http_access deny all")
+	fmt.Fprintln(&b, "cache deny all")
+	fmt.Fprintln(&b, "access_log stdio:/dev/stdout")
+	return b.Bytes()
+}
@@ -0,0 +1,123 @@
+package egress
+
+import (
+	"net/netip"
+	"slices"
+	"strings"
+	"testing"
+
+	"ayayushsharma/rocket/containers"
+)
+
+// Request reaching the proxy, as far as the rendered rules look at it
+type request struct {
+	port string
+	src  string
+	host string
+}
+
+// Evaluates the http_access rules of the config the way squid does for the
+// acl types render writes: the first rule whose acls all match decides
+func allowed(t *testing.T, conf []byte, req request) bool {
+	t.Helper()
+
+	acls := map[string]func(request) bool{}
+	for line := range strings.Lines(string(conf)) {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		switch fields[0] {
+		case "acl":
+			name, kind, values := fields[1], fields[2], fields[3:]
+			switch kind {
+			case "myportname":
+				acls[name] = func(r request) bool { return slices.Contains(values, r.port) }
+			case "src":
+				acls[name] = func(r request) bool {
+					addr := netip.MustParseAddr(r.src)
+					return slices.ContainsFunc(values, func(value string) bool {
+						return netip.MustParsePrefix(value).Contains(addr)
+					})
+				}
+			case "dstdomain":
+				acls[name] = func(r request) bool {
+					return slices.ContainsFunc(values, func(value string) bool {
+						if suffix, ok := strings.CutPrefix(value, "."); ok {
+							return r.host == suffix || strings.HasSuffix(r.host, value)
+						}
+						return r.host == value
+					})
+				}
+			default:
+				acls[name] = func(request) bool { return false }
+			}
+		case "http_access":
+			matched := true
+			for _, name := range fields[2:] {
+				if name == "all" {
+					continue
+				}
+				negate := strings.HasPrefix(name, "!")
+				acl, ok := acls[strings.TrimPrefix(name, "!")]
+				if !ok {
+					t.Fatalf("http_access uses undefined acl %q", name)
+				}
+				if acl(req) == negate {
+					matched = false
+					break
+				}
+			}
+			if matched {
+				return fields[1] == "allow"
+			}
+		}
+	}
+	return false
+}
+
+func TestRenderTiesPortsToApps(t *testing.T) {
+	allowlist := func(hosts ...string) containers.Config {
+		return containers.Config{Egress: containers.Egress{
+			Policy: containers.EgressAllowlist,
+			Allow:  hosts,
+		}}
+	}
+	apps := map[string]containers.Config{
+		"rocket-github":  allowlist("api.github.com"),
+		"rocket-pypi":    allowlist("*.pypi.org"),
+		"rocket-pending": allowlist("example.com"),
+		"rocket-offline": {},
+	}
+	ports := map[string]int{
+		"rocket-github":  3129,
+		"rocket-pypi":    3130,
+		"rocket-pending": 3131,
+	}
+	subnets := map[string][]string{
+		"rocket-github": {"10.89.1.0/24"},
+		"rocket-pypi":   {"10.89.2.0/24"},
+	}
+	conf := render(apps, ports, subnets)
+
+	tests := []struct {
+		name string
+		req  request
+		want bool
+	}{
+		{"app reaches its hosts", request{"rocket-github", "10.89.1.5", "api.github.com"}, true},
+		{"subdomains of wildcard", request{"rocket-pypi", "10.89.2.5", "files.pypi.org"}, true},
+		{"hosts of other apps", request{"rocket-github", "10.89.1.5", "files.pypi.org"}, false},
+		{"port of other app", request{"rocket-pypi", "10.89.1.5", "files.pypi.org"}, false},
+		{"routes network", request{"rocket-github", "10.89.0.7", "api.github.com"}, false},
+		{"app without subnet", request{"rocket-pending", "10.89.3.5", "example.com"}, false},
+		{"default port", request{"default", "10.89.1.5", "api.github.com"}, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := allowed(t, conf, test.req); got != test.want {
+				t.Errorf("allowed(%+v) = %v, want %v\n%s", test.req, got, test.want, conf)
+			}
+		})
+	}
+}
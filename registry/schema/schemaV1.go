@@ -9,12 +9,58 @@ import (
 	"ayayushsharma/rocket/containers"
 )
 
+type registryResourcesV1 struct {
+	Memory     string  `json:"memory"`
+	MemorySwap string  `json:"memorySwap"`
+	CPUs       float64 `json:"cpus"`
+	CPUShares  uint64  `json:"cpuShares"`
+	PidsLimit  int64   `json:"pidsLimit"`
+}
+
+type registryHealthcheckV1 struct {
+	Path        string   `json:"path"`
+	Command     []string `json:"command"`
+	Interval    string   `json:"interval"`
+	Timeout     string   `json:"timeout"`
+	StartPeriod string   `json:"startPeriod"`
+	Retries     int      `json:"retries"`
+}
+
+type registryLifecycleV1 struct {
+	Restart     string `json:"restart"`
+	MaxRetries  uint   `json:"maxRetries"`
+	StopSignal  string `json:"stopSignal"`
+	StopTimeout string `json:"stopTimeout"`
+}
+
+func (l registryLifecycleV1) config() containers.Lifecycle {
+	return containers.Lifecycle{
+		Restart:     l.Restart,
+		MaxRetries:  l.MaxRetries,
+		StopSignal:  l.StopSignal,
+		StopTimeout: l.StopTimeout,
+	}
+}
+
+// Container running next to the app in its pod, e.g. a database
+type registryStackContainerV1 struct {
+	Name           string              `json:"name"`
+	ArtifactoryUrl string              `json:"artifactoryUrl"`
+	Version        string              `json:"version"`
+	Env            map[string]string   `json:"env"`
+	Lifecycle      registryLifecycleV1 `json:"lifecycle"`
+}
+
 type registryAppV1 struct {
-	Name           string `json:"name"`
-	ArtifactoryUrl string `json:"artifactoryUrl"`
-	Version        string `json:"version"`
-	HttpPort       int    `json:"httpPort"`
-	Hostname       string `json:"hostname"`
+	Name           string                     `json:"name"`
+	ArtifactoryUrl string                     `json:"artifactoryUrl"`
+	Version        string                     `json:"version"`
+	HttpPort       int                        `json:"httpPort"`
+	Hostname       string                     `json:"hostname"`
+	Resources      registryResourcesV1        `json:"resources"`
+	Healthcheck    registryHealthcheckV1      `json:"healthcheck"`
+	Lifecycle      registryLifecycleV1        `json:"lifecycle"`
+	Stack          []registryStackContainerV1 `json:"stack"`
 }
 
 type registryV1 struct {
@@ -51,7 +97,34 @@ func parseV1Registry(
 			ImageVersion:    app.Version,
 			SubDomain:       hostName,
 			ExposeHttpPort:  app.HttpPort,
+			Resources: containers.Resources{
+				Memory:     app.Resources.Memory,
+				MemorySwap: app.Resources.MemorySwap,
+				CPUs:       app.Resources.CPUs,
+				CPUShares:  app.Resources.CPUShares,
+				PidsLimit:  app.Resources.PidsLimit,
+			},
+			Healthcheck: containers.Healthcheck{
+				HttpPath:    app.Healthcheck.Path,
+				Command:     app.Healthcheck.Command,
+				Interval:    app.Healthcheck.Interval,
+				Timeout:     app.Healthcheck.Timeout,
+				StartPeriod: app.Healthcheck.StartPeriod,
+				Retries:     app.Healthcheck.Retries,
+			},
+			Lifecycle: app.Lifecycle.config(),
+		}
+		for _, member := range app.Stack {
+			application.Stack = append(application.Stack, containers.Config{
+				ApplicationName: member.Name,
+				ContainerName:   member.Name,
+				ImageURL:        member.ArtifactoryUrl,
+				ImageVersion:    member.Version,
+				EnvValues:       member.Env,
+				Lifecycle:       member.Lifecycle.config(),
+			})
 		}
+
 		parsedData = append(parsedData, application)
 	}
 
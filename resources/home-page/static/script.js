@@ -34,6 +34,13 @@ function renderApps() {
         a.href = app.url;
         a.textContent = app.name;
         li.appendChild(a);
+        // status is only known while `rocket watch` runs
+        if (app.status && app.status !== 'running') {
+            const status = document.createElement('span');
+            status.className = 'app-status';
+            status.textContent = app.status;
+            li.appendChild(status);
+        }
         appList.appendChild(li);
     });
 }
@@ -65,7 +72,8 @@ function debounce(fn, delay = 200) {
 //   "excalidraw.localhost": {
 //     "ContainerURL": "http://rocket-excalidraw-latest:80",
 //     "AppName": "Excalidraw",
-//     "Description": ""
+//     "Description": "",
+//     "Status": "running"
 //   }
 // }
 
@@ -81,7 +89,8 @@ async function pullData() {
             return {
                 name: name.AppName,
                 url: "http://" + url + appPort,
-                description: name.Description
+                description: name.Description,
+                status: name.Status
             }
         });
 
@@ -0,0 +1,353 @@
+// Systemd user units starting the router and registered apps at boot.
+//
+// Units run rocket itself rather than the container runtime, so that apps
+// come up exactly as `rocket launch` creates them, with their stacks, pinned
+// images and trust policy. Every app gets a unit of its own ordered after the
+// router unit.
+
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"ayayushsharma/rocket/common"
+	"ayayushsharma/rocket/constants"
+	"ayayushsharma/rocket/containers"
+)
+
+// Unit starting the router, every app unit depends on it
+const RouterUnit = "rocket-router.service"
+
+// Prefix of app units, followed by the short app name
+const appUnitPrefix = "rocket-app-"
+
+// First line of every generated unit. Only files carrying it are ever
+// overwritten or removed
+const generatedHeader = "# Generated by rocket, changes are overwritten. Manage with `rocket service`"
+
+var SystemctlMissingErr = errors.New("systemctl not found, boot units need systemd")
+
+// How generated units invoke rocket
+type Options struct {
+	// absolute path of the rocket binary
+	Executable string
+
+	// global flags passed on every invocation e.g. `--runtime docker`
+	Args []string
+
+	// container runtime apps run on. Units of podman wait for its socket
+	Runtime string
+}
+
+// State of an installed unit as reported by systemd
+type UnitStatus struct {
+	Unit    string
+	AppName string
+	Enabled string
+	Active  string
+}
+
+// Name of the unit starting the app
+func AppUnit(appName string) string {
+	return appUnitPrefix + common.ShortenAppName(appName) + ".service"
+}
+
+// Whether units were installed. Installs are marked by the router unit
+func Installed() bool {
+	_, err := os.Stat(filepath.Join(constants.SystemdUserDir, RouterUnit))
+	return err == nil
+}
+
+// Writes units for the router and every app not marked for manual start,
+// removes units of apps that no longer need one and enables all of them
+func Install(
+	ctx context.Context,
+	apps map[string]containers.Config,
+	options Options,
+) (err error) {
+	_, err = write(ctx, apps, options)
+	if err != nil {
+		return err
+	}
+	return systemctl(ctx, append([]string{"enable"}, installedUnits()...)...)
+}
+
+// Rewrites installed units after the workspace changed. Only units written
+// for the first time are enabled, so units disabled by hand stay disabled.
+// Does nothing when units are not installed
+func Sync(
+	ctx context.Context,
+	apps map[string]containers.Config,
+	options Options,
+) (err error) {
+	if !Installed() {
+		slog.Debug("Boot units not installed, skipping sync")
+		return nil
+	}
+
+	added, err := write(ctx, apps, options)
+	if err != nil {
+		return err
+	}
+	if len(added) == 0 {
+		return nil
+	}
+	return systemctl(ctx, append([]string{"enable"}, added...)...)
+}
+
+// Disables and removes every generated unit. Running apps are left alone
+func Uninstall(ctx context.Context) (err error) {
+	units := installedUnits()
+	if len(units) == 0 {
+		return nil
+	}
+
+	// apps keep running, they are only no longer started at boot
+	if err = systemctl(ctx, append([]string{"disable"}, units...)...); err != nil {
+		return err
+	}
+	for _, unit := range units {
+		if err = os.Remove(filepath.Join(constants.SystemdUserDir, unit)); err != nil {
+			return err
+		}
+		slog.Debug("Removed unit", "unit", unit)
+	}
+	return systemctl(ctx, "daemon-reload")
+}
+
+// Enablement and activity of every installed unit, router first
+func Status(ctx context.Context) (statuses []UnitStatus, err error) {
+	for _, unit := range installedUnits() {
+		status := UnitStatus{Unit: unit, AppName: unitAppName(unit)}
+		output, err := systemctlOutput(
+			ctx, "show", "--property=UnitFileState,ActiveState", unit,
+		)
+		if err != nil {
+			return nil, err
+		}
+		for line := range strings.Lines(string(output)) {
+			key, value, _ := strings.Cut(strings.TrimSpace(line), "=")
+			switch key {
+			case "UnitFileState":
+				status.Enabled = value
+			case "ActiveState":
+				status.Active = value
+			}
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// Whether the user manager keeps running without a login session. Without
+// lingering user units start on login instead of boot
+func Lingering() bool {
+	_, err := os.Stat(filepath.Join("/var/lib/systemd/linger", os.Getenv("USER")))
+	return err == nil
+}
+
+// Writes units and removes stale ones, then reloads systemd. Returns units
+// that did not exist before
+func write(
+	ctx context.Context,
+	apps map[string]containers.Config,
+	options Options,
+) (added []string, err error) {
+	if err = os.MkdirAll(constants.SystemdUserDir, 0755); err != nil {
+		return nil, err
+	}
+
+	units := Units(apps, options)
+	existing := installedUnits()
+
+	for _, unit := range existing {
+		if _, ok := units[unit]; ok {
+			continue
+		}
+		if err := systemctl(ctx, "disable", unit); err != nil {
+			slog.Debug("Failed to disable stale unit", "unit", unit, "error", err)
+		}
+		if err = os.Remove(filepath.Join(constants.SystemdUserDir, unit)); err != nil {
+			return nil, err
+		}
+		slog.Debug("Removed stale unit", "unit", unit)
+	}
+
+	for _, unit := range slices.Sorted(maps.Keys(units)) {
+		path := filepath.Join(constants.SystemdUserDir, unit)
+		if !slices.Contains(existing, unit) {
+			if _, err := os.Stat(path); err == nil {
+				return nil, fmt.Errorf("%s exists and was not generated by rocket", path)
+			}
+			added = append(added, unit)
+		}
+		if err = os.WriteFile(path, []byte(units[unit]), 0644); err != nil {
+			return nil, err
+		}
+		slog.Debug("Wrote unit", "unit", unit)
+	}
+
+	return added, systemctl(ctx, "daemon-reload")
+}
+
+// Contents of the router unit and units of every app not marked for manual
+// start, keyed by unit name
+func Units(
+	apps map[string]containers.Config,
+	options Options,
+) (units map[string]string) {
+	units = map[string]string{
+		RouterUnit: unit(
+			"rocket router",
+			options,
+			dependencies(options),
+			[]string{"start-router", "--quiet"},
+			[]string{"stop", constants.RouterContainer},
+		),
+	}
+
+	for appName, appCfg := range apps {
+		if appCfg.ManualStart {
+			continue
+		}
+		units[AppUnit(appName)] = unit(
+			fmt.Sprintf("rocket app %s", common.ShortenAppName(appName)),
+			options,
+			[]string{"Requires=" + RouterUnit, "After=" + RouterUnit},
+			[]string{"launch", appName, "--quiet"},
+			[]string{"stop", appName},
+		)
+	}
+	return units
+}
+
+func dependencies(options Options) []string {
+	if options.Runtime != containers.RuntimePodman {
+		return nil
+	}
+	return []string{"Wants=podman.socket", "After=podman.socket"}
+}
+
+func unit(
+	description string,
+	options Options,
+	unitDirectives []string,
+	startArgs []string,
+	stopArgs []string,
+) string {
+	var b strings.Builder
+	fmt.Fprintln(&b, generatedHeader)
+	fmt.Fprintln(&b, "[Unit]")
+	fmt.Fprintf(&b, "Description=%s\n", description)
+	for _, directive := range unitDirectives {
+		fmt.Fprintln(&b, directive)
+	}
+	fmt.Fprintln(&b)
+	fmt.Fprintln(&b, "[Service]")
+	fmt.Fprintln(&b, "Type=oneshot")
+	fmt.Fprintln(&b, "RemainAfterExit=yes")
+	// rocket bounds pulls and stops with its own timeouts
+	fmt.Fprintln(&b, "TimeoutStartSec=0")
+	fmt.Fprintf(&b, "ExecStart=%s\n", command(options, startArgs))
+	fmt.Fprintf(&b, "ExecStop=%s\n", command(options, stopArgs))
+	fmt.Fprintln(&b)
+	fmt.Fprintln(&b, "[Install]")
+	fmt.Fprintln(&b, "WantedBy=default.target")
+	return b.String()
+}
+
+// Command line in systemd syntax. Arguments with spaces or quotes are quoted,
+// specifiers and variables are escaped, so that they reach rocket unchanged
+func command(options Options, args []string) string {
+	words := []string{options.Executable}
+	words = append(words, options.Args...)
+	words = append(words, args...)
+
+	escaper := strings.NewReplacer("%", "%%", "$", "$$")
+	for i, word := range words {
+		word = escaper.Replace(word)
+		if word == "" || strings.ContainsAny(word, " \t\"'\\") {
+			word = `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(word) + `"`
+		}
+		words[i] = word
+	}
+	return strings.Join(words, " ")
+}
+
+// Units generated by rocket present in the unit directory, router first
+func installedUnits() (units []string) {
+	entries, err := os.ReadDir(constants.SystemdUserDir)
+	if err != nil {
+		return nil
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if name != RouterUnit && !strings.HasPrefix(name, appUnitPrefix) {
+			continue
+		}
+		if generated(filepath.Join(constants.SystemdUserDir, name)) {
+			units = append(units, name)
+		}
+	}
+
+	slices.SortFunc(units, func(a, b string) int {
+		if a == RouterUnit {
+			return -1
+		}
+		if b == RouterUnit {
+			return 1
+		}
+		return strings.Compare(a, b)
+	})
+	return units
+}
+
+func generated(path string) bool {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	return bytes.HasPrefix(data, []byte(generatedHeader))
+}
+
+func unitAppName(unit string) string {
+	if unit == RouterUnit {
+		return constants.RouterContainer
+	}
+	name := strings.TrimSuffix(strings.TrimPrefix(unit, appUnitPrefix), ".service")
+	return common.CompleteAppName(name)
+}
+
+func systemctl(ctx context.Context, args ...string) error {
+	_, err := systemctlOutput(ctx, args...)
+	return err
+}
+
+func systemctlOutput(ctx context.Context, args ...string) ([]byte, error) {
+	args = append([]string{"--user"}, args...)
+	slog.Debug("Running systemctl", "args", args)
+
+	output, err := exec.CommandContext(ctx, "systemctl", args...).Output()
+	if errors.Is(err, exec.ErrNotFound) {
+		return nil, SystemctlMissingErr
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return nil, fmt.Errorf(
+			"systemctl %s: %s", strings.Join(args, " "),
+			strings.TrimSpace(string(exitErr.Stderr)),
+		)
+	}
+	return output, err
+}
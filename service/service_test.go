@@ -0,0 +1,162 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"ayayushsharma/rocket/constants"
+	"ayayushsharma/rocket/containers"
+)
+
+func TestCommand(t *testing.T) {
+	tests := []struct {
+		name    string
+		options Options
+		args    []string
+		want    string
+	}{
+		{
+			name:    "plain",
+			options: Options{Executable: "/usr/bin/rocket"},
+			args:    []string{"launch", "rocket-web", "--quiet"},
+			want:    "/usr/bin/rocket launch rocket-web --quiet",
+		},
+		{
+			name: "global flags before command",
+			options: Options{
+				Executable: "/usr/bin/rocket",
+				Args:       []string{"--runtime", "docker"},
+			},
+			args: []string{"stop", "rocket-web"},
+			want: "/usr/bin/rocket --runtime docker stop rocket-web",
+		},
+		{
+			name:    "executable with spaces",
+			options: Options{Executable: "/home/me/my tools/rocket"},
+			args:    []string{"start-router"},
+			want:    `"/home/me/my tools/rocket" start-router`,
+		},
+		{
+			name:    "specifiers",
+			options: Options{Executable: "/opt/100%/rocket"},
+			args:    []string{"launch", "%h"},
+			want:    "/opt/100%%/rocket launch %%h",
+		},
+		{
+			name:    "variables",
+			options: Options{Executable: "/opt/$HOME/rocket"},
+			args:    []string{"launch", "${USER}"},
+			want:    "/opt/$$HOME/rocket launch $${USER}",
+		},
+		{
+			name:    "specifier in quoted word",
+			options: Options{Executable: "/opt/50% off/rocket"},
+			want:    `"/opt/50%% off/rocket"`,
+		},
+		{
+			name:    "quotes and backslashes",
+			options: Options{Executable: "/usr/bin/rocket"},
+			args:    []string{`say "hi"`, `back\slash`, "it's"},
+			want:    `/usr/bin/rocket "say \"hi\"" "back\\slash" "it's"`,
+		},
+		{
+			name:    "empty argument",
+			options: Options{Executable: "/usr/bin/rocket", Args: []string{"--config", ""}},
+			args:    []string{"launch"},
+			want:    `/usr/bin/rocket --config "" launch`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := command(test.options, test.args); got != test.want {
+				t.Errorf("command() = %s\nwant %s", got, test.want)
+			}
+		})
+	}
+}
+
+func TestUnits(t *testing.T) {
+	apps := map[string]containers.Config{
+		"rocket-web":    {ContainerName: "rocket-web"},
+		"rocket-manual": {ContainerName: "rocket-manual", ManualStart: true},
+	}
+	options := Options{
+		Executable: "/home/me/my apps/rocket",
+		Args:       []string{"--runtime", containers.RuntimePodman},
+		Runtime:    containers.RuntimePodman,
+	}
+
+	units := Units(apps, options)
+	if len(units) != 2 {
+		t.Fatalf("Units() generated %d units, want router and web only", len(units))
+	}
+
+	router := units[RouterUnit]
+	wantRouter := generatedHeader + `
+[Unit]
+Description=rocket router
+Wants=podman.socket
+After=podman.socket
+
+[Service]
+Type=oneshot
+RemainAfterExit=yes
+TimeoutStartSec=0
+ExecStart="/home/me/my apps/rocket" --runtime podman start-router --quiet
+ExecStop="/home/me/my apps/rocket" --runtime podman stop ` + constants.RouterContainer + `
+
+[Install]
+WantedBy=default.target
+`
+	if router != wantRouter {
+		t.Errorf("router unit =\n%s\nwant\n%s", router, wantRouter)
+	}
+
+	web, ok := units["rocket-app-web.service"]
+	if !ok {
+		t.Fatalf("no unit for web in %v", units)
+	}
+	wantWeb := generatedHeader + `
+[Unit]
+Description=rocket app web
+Requires=rocket-router.service
+After=rocket-router.service
+
+[Service]
+Type=oneshot
+RemainAfterExit=yes
+TimeoutStartSec=0
+ExecStart="/home/me/my apps/rocket" --runtime podman launch rocket-web --quiet
+ExecStop="/home/me/my apps/rocket" --runtime podman stop rocket-web
+
+[Install]
+WantedBy=default.target
+`
+	if web != wantWeb {
+		t.Errorf("web unit =\n%s\nwant\n%s", web, wantWeb)
+	}
+}
+
+func TestUnitsDockerSkipsPodmanSocket(t *testing.T) {
+	units := Units(nil, Options{
+		Executable: "/usr/bin/rocket",
+		Runtime:    containers.RuntimeDocker,
+	})
+	if strings.Contains(units[RouterUnit], "podman.socket") {
+		t.Errorf("docker router unit waits for podman:\n%s", units[RouterUnit])
+	}
+}
+
+func TestUnitAppName(t *testing.T) {
+	tests := map[string]string{
+		RouterUnit:               constants.RouterContainer,
+		AppUnit("rocket-web"):    "rocket-web",
+		AppUnit("rocket-my-app"): "rocket-my-app",
+	}
+	for unit, want := range tests {
+		if got := unitAppName(unit); got != want {
+			t.Errorf("unitAppName(%q) = %q, want %q", unit, got, want)
+		}
+	}
+}
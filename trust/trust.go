@@ -0,0 +1,114 @@
+// Verification of image signatures before images are run.
+//
+// Policies use the semantics of containers-policy.json(5): requirements can
+// be set per registry, repository or image, with a default for everything
+// else. Apps can additionally require a sigstore (cosign) signature made
+// with their own public key.
+//
+// Signatures are read the same way podman reads them, sigstore signatures
+// are only looked up for registries with `use-sigstore-attachments` enabled
+// in containers-registries.d(5).
+
+package trust
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"go.podman.io/image/v5/docker"
+	"go.podman.io/image/v5/image"
+	"go.podman.io/image/v5/signature"
+	"go.podman.io/image/v5/transports"
+	"go.podman.io/image/v5/types"
+)
+
+var UntrustedImageErr = errors.New("image is not trusted")
+
+// Checks image against the trust policy. Images with a public key must carry
+// a sigstore signature made with it, others are checked against the policy
+// file at policyPath. Images are accepted when neither applies
+func Verify(
+	ctx context.Context,
+	imageRef string,
+	publicKey string,
+	policyPath string,
+) (err error) {
+	policy, err := loadPolicy(publicKey, policyPath)
+	if err != nil {
+		return err
+	}
+	if policy == nil {
+		slog.Debug("No trust policy, image accepted", "image", imageRef)
+		return nil
+	}
+
+	ref, err := docker.ParseReference("//" + imageRef)
+	if err != nil {
+		return fmt.Errorf("parse image %q: %w", imageRef, err)
+	}
+	return verifyImage(ctx, ref, policy)
+}
+
+// Checks the image ref points to against the policy
+func verifyImage(
+	ctx context.Context,
+	ref types.ImageReference,
+	policy *signature.Policy,
+) (err error) {
+	imageRef := transports.ImageName(ref)
+
+	policyContext, err := signature.NewPolicyContext(policy)
+	if err != nil {
+		return err
+	}
+	defer policyContext.Destroy()
+
+	source, err := ref.NewImageSource(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("read image %q: %w", imageRef, err)
+	}
+	defer source.Close()
+
+	allowed, err := policyContext.IsRunningImageAllowed(
+		ctx, image.UnparsedInstance(source, nil),
+	)
+	if !allowed {
+		if err != nil {
+			return fmt.Errorf("%w: %s: %v", UntrustedImageErr, imageRef, err)
+		}
+		return fmt.Errorf("%w: %s", UntrustedImageErr, imageRef)
+	}
+
+	slog.Debug("Image signature verified", "image", imageRef)
+	return nil
+}
+
+// Policy requiring signatures made with publicKey, or the policy file when
+// no key is given. Nil when there is neither
+func loadPolicy(publicKey string, policyPath string) (
+	policy *signature.Policy, err error,
+) {
+	if publicKey != "" {
+		requirement, err := signature.NewPRSigstoreSignedKeyPath(
+			publicKey, signature.NewPRMMatchRepoDigestOrExact(),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("public key %q: %w", publicKey, err)
+		}
+		return &signature.Policy{
+			Default: signature.PolicyRequirements{requirement},
+		}, nil
+	}
+
+	policy, err = signature.NewPolicyFromFile(policyPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("trust policy %q: %w", policyPath, err)
+	}
+	return policy, nil
+}
@@ -0,0 +1,221 @@
+package trust
+
+import (
+	"archive/tar"
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	imgspecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"go.podman.io/image/v5/directory"
+	"go.podman.io/image/v5/signature"
+	"go.podman.io/image/v5/types"
+)
+
+// Identity signatures of the tests are made for
+const testIdentity = "docker.io/library/rocket-test:latest"
+
+// Writes a single layer image in the dir transport layout
+func writeImage(t *testing.T) types.ImageReference {
+	t.Helper()
+
+	dir := t.TempDir()
+	writeBlob := func(data []byte) imgspecv1.Descriptor {
+		t.Helper()
+		blobDigest := digest.FromBytes(data)
+		if err := os.WriteFile(filepath.Join(dir, blobDigest.Encoded()), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return imgspecv1.Descriptor{Digest: blobDigest, Size: int64(len(data))}
+	}
+	marshal := func(value any) []byte {
+		t.Helper()
+		data, err := json.Marshal(value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+
+	var layer bytes.Buffer
+	if err := tar.NewWriter(&layer).Close(); err != nil {
+		t.Fatal(err)
+	}
+	layerDesc := writeBlob(layer.Bytes())
+	layerDesc.MediaType = imgspecv1.MediaTypeImageLayer
+
+	configDesc := writeBlob(marshal(imgspecv1.Image{
+		Platform: imgspecv1.Platform{Architecture: "amd64", OS: "linux"},
+		RootFS: imgspecv1.RootFS{
+			Type:    "layers",
+			DiffIDs: []digest.Digest{layerDesc.Digest},
+		},
+	}))
+	configDesc.MediaType = imgspecv1.MediaTypeImageConfig
+
+	manifest := imgspecv1.Manifest{
+		MediaType: imgspecv1.MediaTypeImageManifest,
+		Config:    configDesc,
+		Layers:    []imgspecv1.Descriptor{layerDesc},
+	}
+	manifest.SchemaVersion = 2
+	if err := os.WriteFile(filepath.Join(dir, "manifest.json"), marshal(manifest), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "version"), []byte("Directory Transport Version: 1.1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ref, err := directory.NewReference(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ref
+}
+
+// Key pair signatures of the tests are made with, along with the path of
+// the PEM encoded public key
+func writeKeyPair(t *testing.T) (privateKey *ecdsa.PrivateKey, publicKey string) {
+	t.Helper()
+
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicKey = filepath.Join(t.TempDir(), "cosign.pub")
+	data := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+	if err = os.WriteFile(publicKey, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return privateKey, publicKey
+}
+
+// Adds a sigstore signature of the image made with the private key, the way
+// cosign signs images as testIdentity
+func signImage(t *testing.T, ref types.ImageReference, privateKey *ecdsa.PrivateKey) {
+	t.Helper()
+
+	dir := ref.StringWithinTransport()
+	manifest, err := os.ReadFile(filepath.Join(dir, "manifest.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload, err := json.Marshal(map[string]any{
+		"critical": map[string]any{
+			"type":     "cosign container image signature",
+			"image":    map[string]string{"docker-manifest-digest": digest.FromBytes(manifest).String()},
+			"identity": map[string]string{"docker-reference": testIdentity},
+		},
+		"optional": nil,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash := sha256.Sum256(payload)
+	signed, err := ecdsa.SignASN1(rand.Reader, privateKey, hash[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	blob, err := json.Marshal(map[string]any{
+		"mimeType": "application/vnd.dev.cosign.simplesigning.v1+json",
+		"payload":  payload,
+		"annotations": map[string]string{
+			"dev.cosignproject.cosign/signature": base64.StdEncoding.EncodeToString(signed),
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// signatures of the dir transport are stored with their format first
+	blob = append([]byte("\x00sigstore-json\n"), blob...)
+	if err = os.WriteFile(filepath.Join(dir, "signature-1"), blob, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Policy requiring signatures of the public key. Images of the dir transport
+// have no name to match, signatures are matched against testIdentity
+func keyPolicy(t *testing.T, publicKey string) *signature.Policy {
+	t.Helper()
+
+	identity, err := signature.NewPRMExactReference(testIdentity)
+	if err != nil {
+		t.Fatal(err)
+	}
+	requirement, err := signature.NewPRSigstoreSignedKeyPath(publicKey, identity)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &signature.Policy{Default: signature.PolicyRequirements{requirement}}
+}
+
+func TestVerifySignedImage(t *testing.T) {
+	privateKey, publicKey := writeKeyPair(t)
+	signed := writeImage(t)
+	signImage(t, signed, privateKey)
+
+	if err := verifyImage(t.Context(), signed, keyPolicy(t, publicKey)); err != nil {
+		t.Fatalf("verifyImage of signed image: %v", err)
+	}
+}
+
+func TestVerifyRefusesUnsignedImage(t *testing.T) {
+	_, publicKey := writeKeyPair(t)
+
+	err := verifyImage(t.Context(), writeImage(t), keyPolicy(t, publicKey))
+	if !errors.Is(err, UntrustedImageErr) {
+		t.Fatalf("verifyImage error = %v, want %v", err, UntrustedImageErr)
+	}
+}
+
+func TestVerifyRefusesOtherKey(t *testing.T) {
+	privateKey, _ := writeKeyPair(t)
+	_, otherPublicKey := writeKeyPair(t)
+	signed := writeImage(t)
+	signImage(t, signed, privateKey)
+
+	err := verifyImage(t.Context(), signed, keyPolicy(t, otherPublicKey))
+	if !errors.Is(err, UntrustedImageErr) {
+		t.Fatalf("verifyImage error = %v, want %v", err, UntrustedImageErr)
+	}
+}
+
+func TestLoadPolicy(t *testing.T) {
+	_, publicKey := writeKeyPair(t)
+	missing := filepath.Join(t.TempDir(), "policy.json")
+
+	policy, err := loadPolicy("", missing)
+	if err != nil || policy != nil {
+		t.Fatalf("loadPolicy without key or file = %v, %v, want no policy", policy, err)
+	}
+
+	policy, err = loadPolicy(publicKey, missing)
+	if err != nil || policy == nil {
+		t.Fatalf("loadPolicy with key = %v, %v, want a policy", policy, err)
+	}
+
+	policyFile := filepath.Join(t.TempDir(), "policy.json")
+	if err = os.WriteFile(policyFile, []byte(`{"default": [{"type": "reject"}]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	policy, err = loadPolicy("", policyFile)
+	if err != nil || policy == nil {
+		t.Fatalf("loadPolicy with policy file = %v, %v, want a policy", policy, err)
+	}
+}
@@ -0,0 +1,53 @@
+package workspace
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+
+	"ayayushsharma/rocket/constants"
+)
+
+// Lock file pinning image versions of the workspace to digests, so that the
+// same workspace launches the same images on every machine
+type lockSchema struct {
+	// image reference with version to the digest it is pinned to
+	Images map[string]string `json:"images"`
+}
+
+// Digests images are pinned to, keyed by image reference with version
+func GetPins() (pins map[string]string, err error) {
+	data, err := os.ReadFile(constants.WorkspaceLockJson)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return map[string]string{}, nil
+		}
+		return nil, err
+	}
+
+	var lock lockSchema
+	if err = json.Unmarshal(data, &lock); err != nil {
+		return nil, err
+	}
+	if lock.Images == nil {
+		lock.Images = map[string]string{}
+	}
+	return lock.Images, nil
+}
+
+// Replaces pins of the lock file
+func SavePins(pins map[string]string) (err error) {
+	jsonData, err := json.MarshalIndent(lockSchema{Images: pins}, "", "  ")
+	if err != nil {
+		return
+	}
+
+	err = os.WriteFile(constants.WorkspaceLockJson, jsonData, 0644)
+	if err != nil {
+		return
+	}
+
+	slog.Debug("Successfully wrote lock file", "pins", len(pins))
+	return nil
+}
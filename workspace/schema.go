@@ -6,10 +6,17 @@ import (
 
 type workspaceSchema struct {
 	Applications map[string]containers.Config `json:"applications"`
+
+	// images pulled by rocket, candidates for garbage collection once no
+	// app uses them
+	PulledImages []string `json:"pulledImages,omitempty"`
 }
 
 type routerData struct {
 	ContainerURL string
 	AppName      string
 	Description  string
+
+	// live state of the app, written while `rocket watch` runs
+	Status string `json:",omitempty"`
 }
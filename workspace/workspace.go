@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
+	"slices"
 
 	// "ayayushsharma/rocket/common"
 	"ayayushsharma/rocket/constants"
@@ -43,7 +44,10 @@ func updateWorkspace(apps map[string]containers.Config) (err error) {
 	}
 
 	workspace.Applications = apps
+	return writeWorkspace(workspace)
+}
 
+func writeWorkspace(workspace workspaceSchema) (err error) {
 	jsonData, err := json.MarshalIndent(workspace, "", "  ")
 	if err != nil {
 		return
@@ -58,6 +62,46 @@ func updateWorkspace(apps map[string]containers.Config) (err error) {
 	return nil
 }
 
+// Images pulled by rocket so far
+func PulledImages() (images []string, err error) {
+	workspace, err := getWorkspace()
+	if err != nil {
+		return
+	}
+	return workspace.PulledImages, nil
+}
+
+// Records image as pulled by rocket. Recording an image twice is a no-op
+func RecordPulledImage(image string) (err error) {
+	workspace, err := getWorkspace()
+	if err != nil {
+		return
+	}
+
+	if slices.Contains(workspace.PulledImages, image) {
+		return nil
+	}
+	workspace.PulledImages = append(workspace.PulledImages, image)
+	slices.Sort(workspace.PulledImages)
+
+	return writeWorkspace(workspace)
+}
+
+// Drops images from the record of pulled images, once they are removed
+func ForgetPulledImages(images []string) (err error) {
+	workspace, err := getWorkspace()
+	if err != nil {
+		return
+	}
+
+	workspace.PulledImages = slices.DeleteFunc(
+		workspace.PulledImages,
+		func(image string) bool { return slices.Contains(images, image) },
+	)
+
+	return writeWorkspace(workspace)
+}
+
 func GetApps() (
 	workspaceApps map[string]containers.Config,
 	err error,
@@ -70,6 +114,16 @@ func GetApps() (
 }
 
 func SyncRouter() (err error) {
+	return writeRoutes(nil)
+}
+
+// Rewrites routes along with the live state of apps, keyed by container
+// name. Apps missing from states are written without one
+func SyncRouterStates(states map[string]string) (err error) {
+	return writeRoutes(states)
+}
+
+func writeRoutes(states map[string]string) (err error) {
 	registry, err := GetApps()
 	if err != nil {
 		return err
@@ -86,6 +140,7 @@ func SyncRouter() (err error) {
 		routes[val.SubDomain] = routerData{
 			ContainerURL: redirectionPort,
 			AppName:      val.ApplicationName,
+			Status:       states[val.ContainerName],
 		}
 	}
 